@@ -1,16 +1,22 @@
 package testutil
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha1"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
 	"encoding/pem"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
+	"unicode/utf16"
 
 	"software.sslmate.com/src/go-pkcs12"
 )
@@ -23,6 +29,8 @@ type CertConfig struct {
 	Province     string
 	Days         int
 	IsCA         bool
+	DNSNames     []string
+	IPAddresses  []net.IP
 }
 
 // CertBundle holds a generated certificate and its key
@@ -63,6 +71,8 @@ func GenerateCertificate(t *testing.T, config CertConfig) *CertBundle {
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 		BasicConstraintsValid: true,
+		DNSNames:              config.DNSNames,
+		IPAddresses:           config.IPAddresses,
 	}
 
 	if config.IsCA {
@@ -121,6 +131,13 @@ func GenerateSignedCertificate(t *testing.T, config CertConfig, ca *CertBundle)
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 		BasicConstraintsValid: true,
+		DNSNames:              config.DNSNames,
+		IPAddresses:           config.IPAddresses,
+	}
+
+	if config.IsCA {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
 	}
 
 	certDER, err := x509.CreateCertificate(rand.Reader, &template, ca.Cert, &privateKey.PublicKey, ca.PrivateKey)
@@ -170,6 +187,126 @@ func CreatePKCS12Bundle(t *testing.T, cert *CertBundle, caCerts []*CertBundle, p
 	return pfxData
 }
 
+// CreatePKCS7Bundle creates a certs-only DER encoded PKCS7 SignedData bundle, like a .p7b file
+func CreatePKCS7Bundle(t *testing.T, certs ...*CertBundle) []byte {
+	t.Helper()
+
+	var rawCerts []byte
+	for _, cert := range certs {
+		rawCerts = append(rawCerts, cert.Cert.Raw...)
+	}
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+
+	signedData, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      struct{ ContentType asn1.ObjectIdentifier }
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		ContentInfo:      struct{ ContentType asn1.ObjectIdentifier }{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawCerts},
+		SignerInfos:      emptySet,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal PKCS7 signed data: %v", err)
+	}
+
+	p7b, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2},
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal PKCS7 content info: %v", err)
+	}
+
+	return p7b
+}
+
+// KeystoreEntry is an entry of a Java keystore created by CreateKeystore
+type KeystoreEntry struct {
+	Alias string
+	// Certs is the chain of a private key entry, or the cert of a trusted cert entry if PrivateKey is false
+	Certs      []*CertBundle
+	PrivateKey bool
+	// SecretKey makes a secret key entry, whose certs are ignored
+	SecretKey bool
+}
+
+// CreateKeystore creates a version 2 JKS keystore, or a JCEKS keystore if jceks is set, protected by password.  The
+// private keys of private key entries are not real encrypted keys, which is enough for reading the certs.
+func CreateKeystore(t *testing.T, jceks bool, password string, entries ...KeystoreEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	write := func(v any) {
+		if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+			t.Fatalf("Failed to write keystore: %v", err)
+		}
+	}
+	writeUTF := func(s string) {
+		write(uint16(len(s)))
+		buf.WriteString(s)
+	}
+	writeCert := func(cert *CertBundle) {
+		writeUTF("X.509")
+		write(uint32(len(cert.Cert.Raw)))
+		buf.Write(cert.Cert.Raw)
+	}
+
+	magic := uint32(0xFEEDFEED)
+	if jceks {
+		magic = 0xCECECECE
+	}
+	write(magic)
+	write(uint32(2))
+	write(uint32(len(entries)))
+	for _, entry := range entries {
+		if entry.SecretKey {
+			write(uint32(3))
+			writeUTF(entry.Alias)
+			write(time.Now().UnixMilli())
+			// A serialized Java object, which like a real one does not store its length
+			buf.Write([]byte{0xAC, 0xED, 0x00, 0x05, 0x73, 0x72})
+			buf.WriteString("com.sun.crypto.provider.SealedObjectForKeyProtector")
+			continue
+		}
+		if entry.PrivateKey {
+			write(uint32(1))
+		} else {
+			write(uint32(2))
+		}
+		writeUTF(entry.Alias)
+		write(time.Now().UnixMilli())
+		if !entry.PrivateKey {
+			writeCert(entry.Certs[0])
+			continue
+		}
+		key := []byte("encrypted private key")
+		write(uint32(len(key)))
+		buf.Write(key)
+		write(uint32(len(entry.Certs)))
+		for _, cert := range entry.Certs {
+			writeCert(cert)
+		}
+	}
+
+	h := sha1.New()
+	for _, c := range utf16.Encode([]rune(password)) {
+		h.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(buf.Bytes())
+	buf.Write(h.Sum(nil))
+
+	return buf.Bytes()
+}
+
 // WriteCertToFile writes a certificate to a file
 func WriteCertToFile(t *testing.T, certPEM []byte, filename string) {
 	t.Helper()
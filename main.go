@@ -1,22 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/joe-elliott/cert-exporter/src/args"
-	"github.com/joe-elliott/cert-exporter/src/checkers"
-	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/config"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
 	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/joe-elliott/cert-exporter/src/runner"
 )
 
 var (
@@ -28,6 +34,8 @@ var (
 var (
 	includeCertGlobs                  args.GlobArgs
 	excludeCertGlobs                  args.GlobArgs
+	certPasswordFile                  string
+	passwordEnv                       string
 	includeKubeConfigGlobs            args.GlobArgs
 	excludeKubeConfigGlobs            args.GlobArgs
 	prometheusExporterMetricsDisabled bool
@@ -38,21 +46,32 @@ var (
 	secretsLabelSelector              args.GlobArgs
 	secretsNamespaceLabelSelector     args.GlobArgs
 	secretsAnnotationSelector         args.GlobArgs
+	secretsLabelsToMetric             args.GlobArgs
 	secretsNamespace                  string
 	secretsListOfNamespaces           string
+	secretsExcludeNamespaces          string
 	includeSecretsDataGlobs           args.GlobArgs
 	excludeSecretsDataGlobs           args.GlobArgs
 	includeSecretsTypes               args.GlobArgs
+	secretsWatch                      bool
+	secretsPasswordKey                string
+	secretsCheckKeypair               bool
+	secretsVerifyChain                bool
+	secretsChainRootsFile             string
+	secretsChainRootsConfigMap        string
 	configMapsLabelSelector           args.GlobArgs
 	configMapsNamespaceLabelSelector  args.GlobArgs
 	configMapsAnnotationSelector      args.GlobArgs
+	configMapsLabelsToMetric          args.GlobArgs
 	configMapsNamespace               string
 	configMapsListOfNamespaces        string
+	configMapsExcludeNamespaces       string
 	includeConfigMapsDataGlobs        args.GlobArgs
 	excludeConfigMapsDataGlobs        args.GlobArgs
 	webhookCheckEnabled               bool
 	webhooksLabelSelector             args.GlobArgs
 	webhooksAnnotationSelector        args.GlobArgs
+	webhooksLabelsToMetric            args.GlobArgs
 	awsAccount                        string
 	awsRegion                         string
 	awsKeySubString                   string
@@ -60,42 +79,92 @@ var (
 	certRequestsEnabled               bool
 	certRequestsLabelSelector         args.GlobArgs
 	certRequestsAnnotationSelector    args.GlobArgs
+	certRequestsLabelsToMetric        args.GlobArgs
 	certRequestsNamespace             string
 	certRequestsListOfNamespaces      string
+	certRequestsExcludeNamespaces     string
+	certificatesEnabled               bool
+	certificatesLabelSelector         args.GlobArgs
+	certificatesAnnotationSelector    args.GlobArgs
+	certificatesNamespace             string
+	certificatesListOfNamespaces      string
+	tlsEndpoints                      args.GlobArgs
+	tlsEndpointTimeout                time.Duration
+	ingressesEnabled                  bool
+	ingressesLabelSelector            args.GlobArgs
+	ingressesAnnotationSelector       args.GlobArgs
+	ingressesNamespace                string
+	ingressesListOfNamespaces         string
+	ingressControllerService          string
+	configFile                        string
+	configReloadInterval              time.Duration
+	shutdownTimeout                   time.Duration
+	degradeOnMissingPermissions       bool
+	kubeAPIQPS                        float64
+	listPageSize                      int64
+	scanConcurrency                   int
+	warnBefore                        time.Duration
+	criticalBefore                    time.Duration
+	kubeAPIBurst                      int
+	kubeUserAgent                     string
 	deprecatedLogtostderr             bool
 )
 
 func init() {
 	flag.Var(&includeCertGlobs, "include-cert-glob", "File globs to include when looking for certs.")
 	flag.Var(&excludeCertGlobs, "exclude-cert-glob", "File globs to exclude when looking for certs.")
+	flag.StringVar(&certPasswordFile, "cert-password-file", "", "File holding the password of the PKCS12 bundles and Java keystores found by the cert globs. A relative path is resolved against the directory of each cert.")
+	flag.StringVar(&passwordEnv, "password-env", "", "Environment variable holding a password to try for PKCS12 bundles and Java keystores in files, secrets and AWS Secrets Manager.")
 	flag.Var(&includeKubeConfigGlobs, "include-kubeconfig-glob", "File globs to include when looking for kubeconfigs.")
 	flag.Var(&excludeKubeConfigGlobs, "exclude-kubeconfig-glob", "File globs to exclude when looking for kubeconfigs.")
 	flag.StringVar(&prometheusPath, "prometheus-path", "/metrics", "The path to publish Prometheus metrics to.")
 	flag.StringVar(&prometheusListenAddress, "prometheus-listen-address", ":8080", "The address to listen on for Prometheus scrapes.")
 	flag.BoolVar(&prometheusExporterMetricsDisabled, "prometheus-disable-exporter-metrics", false, "Exclude metrics about the exporter itself (promhttp_*, process_*, go_*).")
 	flag.DurationVar(&pollingPeriod, "polling-period", time.Hour, "Periodic interval in which to check certs.")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 10*time.Second, "Time to wait for in-flight scrapes to finish on shutdown.")
+	flag.BoolVar(&degradeOnMissingPermissions, "degrade-on-missing-permissions", false, "Skip checkers whose Kubernetes client is unusable or lacks permissions instead of exiting.")
+	flag.StringVar(&configFile, "config", "", "Path to a YAML or JSON file configuring checkers in addition to the ones configured by flags.")
+	flag.DurationVar(&configReloadInterval, "config-reload-interval", 10*time.Second, "Interval in which to check the config file for changes. The configuration is also reloaded on SIGHUP. 0 disables the check.")
 
 	flag.StringVar(&kubeconfigPath, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 0, "Maximum queries per second to the Kubernetes API server per kubeconfig. 0 keeps the client-go default of 5.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 0, "Maximum burst of queries to the Kubernetes API server per kubeconfig. 0 keeps the client-go default of 10.")
+	flag.Int64Var(&listPageSize, "list-page-size", 0, "Number of secrets and configmaps requested per page when listing them (Default 500).")
+	flag.IntVar(&scanConcurrency, "scan-concurrency", 0, "Number of list calls and of objects parsed at once when scanning secrets and configmaps (Default 4).")
+	flag.DurationVar(&warnBefore, "warn-before", 0, "Default time before expiry at which cert_exporter_cert_status reports certs in secrets, configmaps and certrequests as a warning. Objects may override it with the cert-exporter.io/warn-before annotation (Default 720h).")
+	flag.DurationVar(&criticalBefore, "critical-before", 0, "Default time before expiry at which cert_exporter_cert_status reports certs in secrets, configmaps and certrequests as critical. Objects may override it with the cert-exporter.io/critical-before annotation (Default 168h).")
+	flag.StringVar(&kubeUserAgent, "kube-user-agent", "", "User agent sent to the Kubernetes API server (Default \"cert-exporter/<version>\").")
 	flag.Var(&secretsLabelSelector, "secrets-label-selector", "Label selector to find secrets to publish as metrics.")
 	flag.Var(&secretsNamespaceLabelSelector, "secrets-namespace-label-selector", "Label selector to find namespaces in which to find secrets to publish as metrics.")
-	flag.Var(&secretsAnnotationSelector, "secrets-annotation-selector", "Annotation selector to find secrets to publish as metrics.")
+	flag.Var(&secretsAnnotationSelector, "secrets-annotation-selector", "Annotation selector to find secrets to publish as metrics, e.g. key, key=value, key!=value, key in (a,b) or !key.")
+	flag.Var(&secretsLabelsToMetric, "secrets-label-to-metric", "Label of the secret to copy to cert_exporter_secret_labels as label_<name>, or annotation to copy as annotation_<name> if prefixed with annotation:.")
 	flag.StringVar(&secretsNamespace, "secrets-namespace", "", "Kubernetes namespace to list secrets.")
 	flag.StringVar(&secretsListOfNamespaces, "secrets-namespaces", "", "Kubernetes comma-delimited list of namespaces to search for secrets.")
+	flag.StringVar(&secretsExcludeNamespaces, "secrets-exclude-namespaces", "", "Kubernetes comma-delimited list of namespaces or globs such as team-* to skip when searching for secrets.")
 	flag.Var(&includeSecretsDataGlobs, "secrets-include-glob", "Secret globs to include when looking for secret data keys (Default \"*\").")
 	flag.Var(&includeSecretsTypes, "secret-include-types", "Select only specific a secret type (Default nil).")
 	flag.Var(&excludeSecretsDataGlobs, "secrets-exclude-glob", "Secret globs to exclude when looking for secret data keys.")
+	flag.BoolVar(&secretsWatch, "secrets-watch", false, "Watch secrets with shared informers and update metrics as they change instead of listing them every polling period.")
+	flag.BoolVar(&secretsCheckKeypair, "secrets-check-keypair", false, "Check that the private key of kubernetes.io/tls secrets belongs to their cert and export cert_exporter_secret_keypair_match.")
+	flag.BoolVar(&secretsVerifyChain, "secrets-verify-chain", false, "Verify the cert chain of kubernetes.io/tls secrets against the chain roots and export cert_exporter_secret_chain_valid.")
+	flag.StringVar(&secretsChainRootsFile, "secrets-chain-roots-file", "", "PEM bundle of the root certs chains are verified against instead of the system roots.")
+	flag.StringVar(&secretsChainRootsConfigMap, "secrets-chain-roots-configmap", "", "Configmap holding the root certs chains are verified against instead of the system roots, as namespace/name.")
+	flag.StringVar(&secretsPasswordKey, "secrets-password-key", "", "Secret data key holding the password of the PKCS12 bundles and Java keystores in the same secret (Default \"password\").")
 
 	flag.Var(&configMapsLabelSelector, "configmaps-label-selector", "Label selector to find configmaps to publish as metrics.")
 	flag.Var(&configMapsNamespaceLabelSelector, "configmaps-namespace-label-selector", "Label selector to find namespaces in which to find configmaps to publish as metrics.")
-	flag.Var(&configMapsAnnotationSelector, "configmaps-annotation-selector", "Annotation selector to find configmaps to publish as metrics.")
+	flag.Var(&configMapsAnnotationSelector, "configmaps-annotation-selector", "Annotation selector to find configmaps to publish as metrics, e.g. key, key=value, key!=value, key in (a,b) or !key.")
+	flag.Var(&configMapsLabelsToMetric, "configmaps-label-to-metric", "Label of the configmap to copy to cert_exporter_configmap_labels as label_<name>, or annotation to copy as annotation_<name> if prefixed with annotation:.")
 	flag.StringVar(&configMapsNamespace, "configmaps-namespace", "", "Kubernetes namespace to list configmaps.")
 	flag.StringVar(&configMapsListOfNamespaces, "configmaps-namespaces", "", "Kubernetes comma-delimited list of namespaces to search for configmaps.")
+	flag.StringVar(&configMapsExcludeNamespaces, "configmaps-exclude-namespaces", "", "Kubernetes comma-delimited list of namespaces or globs such as team-* to skip when searching for configmaps.")
 	flag.Var(&includeConfigMapsDataGlobs, "configmaps-include-glob", "Configmap globs to include when looking for configmap data keys (Default \"*\").")
 	flag.Var(&excludeConfigMapsDataGlobs, "configmaps-exclude-glob", "Configmap globs to exclude when looking for configmap data keys.")
 
 	flag.BoolVar(&webhookCheckEnabled, "enable-webhook-cert-check", false, "Enable webhook cert check.")
 	flag.Var(&webhooksLabelSelector, "webhooks-label-selector", "Label selector to find webhooks to publish as metrics.")
-	flag.Var(&webhooksAnnotationSelector, "webhooks-annotation-selector", "Annotation selector to find webhooks to publish as metrics.")
+	flag.Var(&webhooksAnnotationSelector, "webhooks-annotation-selector", "Annotation selector to find webhooks to publish as metrics, e.g. key, key=value, key!=value, key in (a,b) or !key.")
+	flag.Var(&webhooksLabelsToMetric, "webhooks-label-to-metric", "Label of the webhook configuration to copy to cert_exporter_webhook_labels as label_<name>, or annotation to copy as annotation_<name> if prefixed with annotation:.")
 
 	flag.StringVar(&awsAccount, "aws-account", "", "AWS account to search for secrets in")
 	flag.StringVar(&awsRegion, "aws-region", "", "AWS region to search for secrets in")
@@ -104,9 +173,27 @@ func init() {
 
 	flag.BoolVar(&certRequestsEnabled, "enable-certrequests-check", false, "Enable certrequests check.")
 	flag.Var(&certRequestsLabelSelector, "certrequests-label-selector", "Label selector to find certrequests to publish as metrics.")
-	flag.Var(&certRequestsAnnotationSelector, "certrequests-annotation-selector", "Annotation selector to find certrequests to publish as metrics.")
+	flag.Var(&certRequestsAnnotationSelector, "certrequests-annotation-selector", "Annotation selector to find certrequests to publish as metrics, e.g. key, key=value, key!=value, key in (a,b) or !key.")
+	flag.Var(&certRequestsLabelsToMetric, "certrequests-label-to-metric", "Label of the certrequest to copy to cert_exporter_certrequest_labels as label_<name>, or annotation to copy as annotation_<name> if prefixed with annotation:.")
 	flag.StringVar(&certRequestsNamespace, "certrequests-namespace", "", "Kubernetes namespace to list certrequests.")
 	flag.StringVar(&certRequestsListOfNamespaces, "certrequests-namespaces", "", "Kubernetes comma-delimited list of namespaces to search for certrequests.")
+	flag.StringVar(&certRequestsExcludeNamespaces, "certrequests-exclude-namespaces", "", "Kubernetes comma-delimited list of namespaces or globs such as team-* to skip when searching for certrequests.")
+
+	flag.BoolVar(&certificatesEnabled, "enable-certificates-check", false, "Enable cert-manager certificates check.")
+	flag.Var(&certificatesLabelSelector, "certificates-label-selector", "Label selector to find cert-manager certificates to publish as metrics.")
+	flag.Var(&certificatesAnnotationSelector, "certificates-annotation-selector", "Annotation selector to find cert-manager certificates to publish as metrics, e.g. key, key=value, key!=value, key in (a,b) or !key.")
+	flag.StringVar(&certificatesNamespace, "certificates-namespace", "", "Kubernetes namespace to list cert-manager certificates.")
+	flag.StringVar(&certificatesListOfNamespaces, "certificates-namespaces", "", "Kubernetes comma-delimited list of namespaces to search for cert-manager certificates.")
+
+	flag.Var(&tlsEndpoints, "tls-endpoint", "TLS endpoint to probe in the form host:port[,sni=name][,starttls=smtp|imap|postgres][,timeout=duration].")
+	flag.DurationVar(&tlsEndpointTimeout, "tls-endpoint-timeout", 10*time.Second, "Default timeout for probing a TLS endpoint.")
+
+	flag.BoolVar(&ingressesEnabled, "enable-ingress-check", false, "Enable ingress TLS check.")
+	flag.Var(&ingressesLabelSelector, "ingresses-label-selector", "Label selector to find ingresses to publish as metrics.")
+	flag.Var(&ingressesAnnotationSelector, "ingresses-annotation-selector", "Annotation selector to find ingresses to publish as metrics, e.g. key, key=value, key!=value, key in (a,b) or !key.")
+	flag.StringVar(&ingressesNamespace, "ingresses-namespace", "", "Kubernetes namespace to list ingresses.")
+	flag.StringVar(&ingressesListOfNamespaces, "ingresses-namespaces", "", "Kubernetes comma-delimited list of namespaces to search for ingresses.")
+	flag.StringVar(&ingressControllerService, "ingress-controller-service", "", "Ingress controller service in the form namespace/name[:port] used to probe the certs served for ingress hosts.")
 
 	flag.BoolVar(&deprecatedLogtostderr, "logtostderr", true, "DEPRECATED: This flag is no longer used. Logs are always written to stderr.")
 }
@@ -125,78 +212,270 @@ func main() {
 	slog.Info("Starting cert-exporter", "version", version, "commit", commit, "date", date)
 	slog.Info("pprof profiling endpoints available at /debug/pprof/")
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if kubeUserAgent == "" {
+		kubeUserAgent = "cert-exporter/" + version
+	}
+	clients := kubeclient.NewClients(ctx, kubeclient.Options{
+		QPS:       float32(kubeAPIQPS),
+		Burst:     kubeAPIBurst,
+		UserAgent: kubeUserAgent,
+	})
+
+	checkerRunner := runner.New(os.Getenv("NODE_NAME"), clients, degradeOnMissingPermissions)
+	if err := checkerRunner.Apply(ctx, cfg); err != nil {
+		log.Fatalf("Unusable Kubernetes client configuration, pass --degrade-on-missing-permissions to start the other checkers anyway:\n%v", err)
+	}
+	metrics.ConfigLastReloadSuccess.Set(1)
+	metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+
+	go watchConfig(ctx, checkerRunner)
+
+	handler := promhttp.HandlerFor(metrics.NewGatherer(prometheus.DefaultGatherer), promhttp.HandlerOpts{})
+
+	if !prometheusExporterMetricsDisabled {
+		handler = promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, handler)
+	}
+
+	http.Handle(prometheusPath, handler)
+	http.Handle("/healthz", probeHandler(checkerRunner.Live))
+	http.Handle("/readyz", probeHandler(checkerRunner.Ready))
+
+	server := &http.Server{Addr: prometheusListenAddress}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("Shutting down")
+
+	// Stop serving scrapes before the checkers remove their metrics
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Error shutting down HTTP server", "error", err)
+	}
+
+	checkerRunner.Stop()
+	slog.Info("Shutdown complete")
+}
+
+// probeHandler serves a Kubernetes probe that fails with 503 while check returns an error
+func probeHandler(check func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// flagConfig returns the checkers configured by command line flags
+func flagConfig() *config.Config {
+	cfg := &config.Config{
+		Kubeconfig:      kubeconfigPath,
+		ListPageSize:    listPageSize,
+		ScanConcurrency: scanConcurrency,
+		Thresholds:      config.Thresholds{WarnBefore: warnBefore, CriticalBefore: criticalBefore},
+	}
+
+	// Leave the polling period to the config file unless it was set explicitly
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "polling-period" {
+			cfg.PollingPeriod = pollingPeriod
+		}
+	})
+
 	if len(includeCertGlobs) > 0 {
-		certChecker := checkers.NewCertChecker(pollingPeriod, includeCertGlobs, excludeCertGlobs, os.Getenv("NODE_NAME"), &exporters.CertExporter{})
-		go certChecker.StartChecking()
+		cfg.Certs = append(cfg.Certs, config.FileConfig{
+			IncludeGlobs:    includeCertGlobs,
+			ExcludeGlobs:    excludeCertGlobs,
+			PasswordFile:    certPasswordFile,
+			PasswordSources: config.PasswordSources{PasswordEnv: passwordEnv},
+		})
 	}
 
 	if len(includeKubeConfigGlobs) > 0 {
-		configChecker := checkers.NewCertChecker(pollingPeriod, includeKubeConfigGlobs, excludeKubeConfigGlobs, os.Getenv("NODE_NAME"), &exporters.KubeConfigExporter{})
-		go configChecker.StartChecking()
+		cfg.KubeConfigs = append(cfg.KubeConfigs, config.FileConfig{
+			IncludeGlobs: includeKubeConfigGlobs,
+			ExcludeGlobs: excludeKubeConfigGlobs,
+		})
 	}
 
 	if len(secretsLabelSelector) > 0 || len(secretsAnnotationSelector) > 0 || len(includeSecretsDataGlobs) > 0 || len(secretsNamespaceLabelSelector) > 0 {
-		if len(includeSecretsDataGlobs) == 0 {
-			includeSecretsDataGlobs = args.GlobArgs([]string{"*"})
-		}
-		secretsNamespaces := getSanitizedNamespaceList(secretsListOfNamespaces, secretsNamespace)
-
-		configChecker := checkers.NewSecretChecker(pollingPeriod, secretsLabelSelector, includeSecretsDataGlobs, excludeSecretsDataGlobs, secretsAnnotationSelector, secretsNamespaces, secretsNamespaceLabelSelector, kubeconfigPath, &exporters.SecretExporter{}, includeSecretsTypes)
-		go configChecker.StartChecking()
+		cfg.Secrets = append(cfg.Secrets, config.SecretConfig{
+			LabelSelectors:          secretsLabelSelector,
+			AnnotationSelectors:     secretsAnnotationSelector,
+			LabelsToMetric:          secretsLabelsToMetric,
+			Namespaces:              getSanitizedNamespaceList(secretsListOfNamespaces, secretsNamespace),
+			ExcludeNamespaces:       splitNamespaceList(secretsExcludeNamespaces),
+			NamespaceLabelSelectors: secretsNamespaceLabelSelector,
+			IncludeGlobs:            includeSecretsDataGlobs,
+			ExcludeGlobs:            excludeSecretsDataGlobs,
+			IncludeTypes:            includeSecretsTypes,
+			Watch:                   secretsWatch,
+			PasswordKey:             secretsPasswordKey,
+			CheckKeypair:            secretsCheckKeypair,
+			VerifyChain:             secretsVerifyChain,
+			ChainRootsFile:          secretsChainRootsFile,
+			ChainRootsConfigMap:     secretsChainRootsConfigMap,
+			PasswordSources:         config.PasswordSources{PasswordEnv: passwordEnv},
+		})
 	}
 
 	if len(certRequestsLabelSelector) > 0 || len(certRequestsAnnotationSelector) > 0 || certRequestsEnabled {
-		certRequestNamespaces := getSanitizedNamespaceList(certRequestsListOfNamespaces, certRequestsNamespace)
-
-		configChecker := checkers.NewCertRequestChecker(pollingPeriod, certRequestsLabelSelector, certRequestsAnnotationSelector, certRequestNamespaces, kubeconfigPath, &exporters.CertRequestExporter{})
-		go configChecker.StartChecking()
+		cfg.CertRequests = append(cfg.CertRequests, config.CertRequestConfig{
+			LabelSelectors:      certRequestsLabelSelector,
+			AnnotationSelectors: certRequestsAnnotationSelector,
+			LabelsToMetric:      certRequestsLabelsToMetric,
+			Namespaces:          getSanitizedNamespaceList(certRequestsListOfNamespaces, certRequestsNamespace),
+			ExcludeNamespaces:   splitNamespaceList(certRequestsExcludeNamespaces),
+		})
+	}
 
+	if len(certificatesLabelSelector) > 0 || len(certificatesAnnotationSelector) > 0 || certificatesEnabled {
+		cfg.Certificates = append(cfg.Certificates, config.CertificateConfig{
+			LabelSelectors:      certificatesLabelSelector,
+			AnnotationSelectors: certificatesAnnotationSelector,
+			Namespaces:          getSanitizedNamespaceList(certificatesListOfNamespaces, certificatesNamespace),
+		})
 	}
 
 	if len(awsAccount) > 0 && len(awsRegion) > 0 && len(awsSecrets) > 0 {
-		slog.Info("Starting check for AWS Secrets Manager", "account", awsAccount, "region", awsRegion, "secrets", awsSecrets)
-		awsChecker := checkers.NewAwsChecker(awsAccount, awsRegion, awsKeySubString, awsSecrets, pollingPeriod, &exporters.AwsExporter{})
-		go awsChecker.StartChecking()
+		cfg.Aws = append(cfg.Aws, config.AwsConfig{
+			Account:         awsAccount,
+			Region:          awsRegion,
+			KeySubString:    awsKeySubString,
+			Secrets:         awsSecrets,
+			PasswordSources: config.PasswordSources{PasswordEnv: passwordEnv},
+		})
 	}
 
 	if len(configMapsLabelSelector) > 0 || len(configMapsAnnotationSelector) > 0 || len(includeConfigMapsDataGlobs) > 0 || len(configMapsNamespaceLabelSelector) > 0 {
-		if len(includeConfigMapsDataGlobs) == 0 {
-			includeConfigMapsDataGlobs = args.GlobArgs([]string{"*"})
+		cfg.ConfigMaps = append(cfg.ConfigMaps, config.ConfigMapConfig{
+			LabelSelectors:          configMapsLabelSelector,
+			AnnotationSelectors:     configMapsAnnotationSelector,
+			LabelsToMetric:          configMapsLabelsToMetric,
+			Namespaces:              getSanitizedNamespaceList(configMapsListOfNamespaces, configMapsNamespace),
+			ExcludeNamespaces:       splitNamespaceList(configMapsExcludeNamespaces),
+			NamespaceLabelSelectors: configMapsNamespaceLabelSelector,
+			IncludeGlobs:            includeConfigMapsDataGlobs,
+			ExcludeGlobs:            excludeConfigMapsDataGlobs,
+		})
+	}
+
+	if webhookCheckEnabled {
+		cfg.Webhooks = append(cfg.Webhooks, config.WebhookConfig{
+			LabelSelectors:      webhooksLabelSelector,
+			AnnotationSelectors: webhooksAnnotationSelector,
+			LabelsToMetric:      webhooksLabelsToMetric,
+		})
+	}
+
+	if len(tlsEndpoints) > 0 {
+		cfg.TLSEndpoints = append(cfg.TLSEndpoints, config.TLSEndpointConfig{
+			Targets: tlsEndpoints,
+			Timeout: tlsEndpointTimeout,
+		})
+	}
+
+	if len(ingressesLabelSelector) > 0 || len(ingressesAnnotationSelector) > 0 || ingressesEnabled {
+		cfg.Ingresses = append(cfg.Ingresses, config.IngressConfig{
+			LabelSelectors:      ingressesLabelSelector,
+			AnnotationSelectors: ingressesAnnotationSelector,
+			Namespaces:          getSanitizedNamespaceList(ingressesListOfNamespaces, ingressesNamespace),
+			ControllerService:   ingressControllerService,
+			ProbeTimeout:        tlsEndpointTimeout,
+		})
+	}
+
+	return cfg
+}
+
+// loadConfig builds the config from flags and the config file and validates it
+func loadConfig() (*config.Config, error) {
+	cfg := flagConfig()
+	if configFile != "" {
+		fileConfig, err := config.Load(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file %s: %w", configFile, err)
 		}
-		configMapsNamespaces := getSanitizedNamespaceList(configMapsListOfNamespaces, configMapsNamespace)
+		cfg.Merge(fileConfig)
+	}
+	cfg.ApplyDefaults()
 
-		configChecker := checkers.NewConfigMapChecker(pollingPeriod, configMapsLabelSelector, includeConfigMapsDataGlobs, excludeConfigMapsDataGlobs, configMapsAnnotationSelector, configMapsNamespaces, configMapsNamespaceLabelSelector, kubeconfigPath, &exporters.ConfigMapExporter{})
-		go configChecker.StartChecking()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
 	}
 
-	if webhookCheckEnabled {
-		configChecker := checkers.NewWebhookChecker(pollingPeriod, webhooksLabelSelector, webhooksAnnotationSelector, kubeconfigPath, &exporters.WebhookExporter{})
-		go configChecker.StartChecking()
+	return cfg, nil
+}
+
+// reloadConfig applies a changed config.  The running checkers are left alone if the new config is invalid.
+func reloadConfig(ctx context.Context, r *runner.Runner) {
+	slog.Info("Reloading configuration")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		slog.Error("Error reloading configuration, keeping the previous one", "error", err)
+		metrics.ErrorTotal.Inc()
+		metrics.ConfigLastReloadSuccess.Set(0)
+		return
 	}
 
-	handler := promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{})
+	if err := r.Apply(ctx, cfg); err != nil {
+		slog.Error("Error applying configuration, keeping the previous one", "error", err)
+		metrics.ErrorTotal.Inc()
+		metrics.ConfigLastReloadSuccess.Set(0)
+		return
+	}
+	metrics.ConfigLastReloadSuccess.Set(1)
+	metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+}
 
-	if !prometheusExporterMetricsDisabled {
-		handler = promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, handler)
+// watchConfig reloads the configuration on SIGHUP and whenever the config file changes
+func watchConfig(ctx context.Context, r *runner.Runner) {
+	reload := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case reload <- struct{}{}:
+		default:
+		}
 	}
 
-	http.Handle(prometheusPath, handler)
-	log.Fatal(http.ListenAndServe(prometheusListenAddress, nil))
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	if configFile != "" && configReloadInterval > 0 {
+		go config.WatchFile(ctx, configFile, configReloadInterval, trigger)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reloadConfig(ctx, r)
+		case <-reload:
+			reloadConfig(ctx, r)
+		}
+	}
 }
 
 // Get the trimmed and sanitized list of namespaces
 func getSanitizedNamespaceList(rawListOfNamespaces, namespace string) []string {
-	var selected []string
-
-	if len(rawListOfNamespaces) > 0 {
-		provided := strings.Split(rawListOfNamespaces, ",")
-		for _, v := range provided {
-			v = strings.TrimSpace(v)
-			if v != "" {
-				selected = append(selected, v)
-			}
-		}
-	}
+	selected := splitNamespaceList(rawListOfNamespaces)
 
 	if len(namespace) > 0 {
 		selected = append(selected, namespace)
@@ -208,3 +487,15 @@ func getSanitizedNamespaceList(rawListOfNamespaces, namespace string) []string {
 
 	return selected
 }
+
+// splitNamespaceList returns the trimmed, non-empty entries of a comma-delimited list of namespaces
+func splitNamespaceList(rawListOfNamespaces string) []string {
+	var namespaces []string
+	for _, v := range strings.Split(rawListOfNamespaces, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			namespaces = append(namespaces, v)
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,249 @@
+package checkers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+)
+
+// Annotations overriding the expiry thresholds of the certs of a secret, configmap or certrequest
+const (
+	WarnBeforeAnnotation     = "cert-exporter.io/warn-before"
+	CriticalBeforeAnnotation = "cert-exporter.io/critical-before"
+)
+
+// Annotations referencing another secret in the same namespace that holds the password of the PKCS12 bundles and Java
+// keystores of a secret.  The key defaults to the checker's password key.
+const (
+	PasswordSecretAnnotation    = "cert-exporter.io/password-secret"
+	PasswordSecretKeyAnnotation = "cert-exporter.io/password-secret-key"
+)
+
+// matchAnnotations reports whether annotations match any of the selectors, or whether there are no selectors.
+// Selectors use label selector syntax, e.g. key, !key, key=value, key!=value or key in (a,b), and every
+// comma-separated requirement of a selector has to match.  A selector that is just a key matches objects having the
+// annotation, whatever its value.  Unlike label values, values may be anything but commas, e.g. URLs or text with
+// spaces, and * and ? in a value match any run of characters and any single character.
+func matchAnnotations(selectors []string, annotations map[string]string) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+
+	for _, s := range selectors {
+		// Selectors are validated with the config, one that does not parse matches nothing
+		selector, err := parseAnnotationSelector(s)
+		if err == nil && selector.matches(annotations) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAnnotationSelectors checks annotation selectors as taken by matchAnnotations
+func ValidateAnnotationSelectors(selectors []string) error {
+	var errs []error
+	for _, s := range selectors {
+		if _, err := parseAnnotationSelector(s); err != nil {
+			errs = append(errs, fmt.Errorf("invalid annotation selector %q: %w", s, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// annotationSelector holds the requirements of an annotation selector, all of which have to match
+type annotationSelector []annotationRequirement
+
+// annotationRequirement is a single requirement of an annotation selector.  values are the value globs of the =, !=,
+// in and notin operators.
+type annotationRequirement struct {
+	key      string
+	operator selection.Operator
+	values   []*regexp.Regexp
+}
+
+// parseAnnotationSelector splits a selector into its requirements.  Keys are validated like label keys, which share
+// the syntax of annotation keys, but values are taken as they are.
+func parseAnnotationSelector(s string) (annotationSelector, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	parts, err := splitRequirements(s)
+	if err != nil {
+		return nil, err
+	}
+	selector := make(annotationSelector, 0, len(parts))
+	for _, part := range parts {
+		requirement, err := parseAnnotationRequirement(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, requirement)
+	}
+	return selector, nil
+}
+
+// splitRequirements splits a selector at the commas outside of parentheses
+func splitRequirements(s string) ([]string, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unexpected )")
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("missing )")
+	}
+	return append(parts, s[start:]), nil
+}
+
+// parseAnnotationRequirement parses a single requirement: key, !key, key=value, key==value, key!=value,
+// key in (a,b) or key notin (a,b)
+func parseAnnotationRequirement(s string) (annotationRequirement, error) {
+	if s == "" {
+		return annotationRequirement{}, fmt.Errorf("empty requirement")
+	}
+	if key, ok := strings.CutPrefix(s, "!"); ok {
+		return newAnnotationRequirement(strings.TrimSpace(key), selection.DoesNotExist, nil)
+	}
+
+	i := strings.IndexAny(s, "=! \t(")
+	if i < 0 {
+		return newAnnotationRequirement(s, selection.Exists, nil)
+	}
+	key, rest := s[:i], strings.TrimSpace(s[i:])
+	for _, op := range []selection.Operator{selection.DoubleEquals, selection.NotEquals, selection.Equals} {
+		if value, ok := strings.CutPrefix(rest, string(op)); ok {
+			if op == selection.DoubleEquals {
+				op = selection.Equals
+			}
+			return newAnnotationRequirement(key, op, []string{strings.TrimSpace(value)})
+		}
+	}
+
+	word, list, ok := strings.Cut(rest, "(")
+	list, closed := strings.CutSuffix(strings.TrimSpace(list), ")")
+	op := selection.Operator(strings.TrimSpace(word))
+	if !ok || !closed || (op != selection.In && op != selection.NotIn) {
+		return annotationRequirement{}, fmt.Errorf("expected =, !=, in or notin after %s", key)
+	}
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return annotationRequirement{}, fmt.Errorf("%s %s needs at least one value", key, op)
+	}
+	return newAnnotationRequirement(key, op, values)
+}
+
+// newAnnotationRequirement validates key and compiles the value globs
+func newAnnotationRequirement(key string, operator selection.Operator, values []string) (annotationRequirement, error) {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return annotationRequirement{}, fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, "; "))
+	}
+
+	requirement := annotationRequirement{key: key, operator: operator}
+	for _, value := range values {
+		pattern := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(value))
+		requirement.values = append(requirement.values, regexp.MustCompile("(?s)^"+pattern+"$"))
+	}
+	return requirement, nil
+}
+
+// matches reports whether annotations meet every requirement of the selector
+func (s annotationSelector) matches(annotations map[string]string) bool {
+	for _, requirement := range s {
+		if !requirement.matches(annotations) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether annotations meet the requirement.  Like in label selectors, != and notin match objects
+// without the annotation.
+func (r annotationRequirement) matches(annotations map[string]string) bool {
+	value, ok := annotations[r.key]
+	switch r.operator {
+	case selection.Exists:
+		return ok
+	case selection.DoesNotExist:
+		return !ok
+	case selection.Equals, selection.In:
+		return ok && r.matchesValue(value)
+	default:
+		return !ok || !r.matchesValue(value)
+	}
+}
+
+// matchesValue reports whether value matches any of the value globs
+func (r annotationRequirement) matchesValue(value string) bool {
+	for _, glob := range r.values {
+		if glob.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// expiryThresholds returns the thresholds for the certs of an object, taken from its annotations where they are set
+// and from defaults otherwise.  A default is capped by the annotation that is set, so annotating only warn-before below
+// the default critical-before lowers critical-before to it, and annotating only critical-before above the default
+// warn-before raises warn-before to it.  An annotation that is not a duration such as 720h falls back to the default
+// and is returned as an error, as do both annotations if they set critical-before greater than warn-before.
+func expiryThresholds(defaults exporters.ExpiryThresholds, annotations map[string]string) (exporters.ExpiryThresholds, error) {
+	thresholds := defaults
+	set := map[string]bool{}
+	var errs []error
+	for annotation, threshold := range map[string]*time.Duration{
+		WarnBeforeAnnotation:     &thresholds.WarnBefore,
+		CriticalBeforeAnnotation: &thresholds.CriticalBefore,
+	} {
+		value, ok := annotations[annotation]
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s annotation: %w", annotation, err))
+			continue
+		}
+		*threshold = d
+		set[annotation] = true
+	}
+	if thresholds.CriticalBefore <= thresholds.WarnBefore {
+		return thresholds, errors.Join(errs...)
+	}
+	switch {
+	case set[WarnBeforeAnnotation] && set[CriticalBeforeAnnotation]:
+		errs = append(errs, fmt.Errorf("%s %v must not be greater than %s %v", CriticalBeforeAnnotation, thresholds.CriticalBefore, WarnBeforeAnnotation, thresholds.WarnBefore))
+		thresholds = defaults
+	case set[WarnBeforeAnnotation]:
+		thresholds.CriticalBefore = thresholds.WarnBefore
+	case set[CriticalBeforeAnnotation]:
+		thresholds.WarnBefore = thresholds.CriticalBefore
+	}
+	return thresholds, errors.Join(errs...)
+}
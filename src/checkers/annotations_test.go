@@ -0,0 +1,104 @@
+package checkers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+)
+
+func TestMatchAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		"cert-manager.io/certificate-name": "web",
+		"cert-exporter.io/scrape":          "true",
+		"cert-exporter.io/team":            "payments",
+		"example.com/source":               "https://git.example.com/team/certs",
+		"example.com/description":          "Front door cert (public)",
+	}
+
+	tests := []struct {
+		name      string
+		selectors []string
+		want      bool
+	}{
+		{"no selectors", nil, true},
+		{"key", []string{"cert-manager.io/certificate-name"}, true},
+		{"missing key", []string{"cert-exporter.io/skip"}, false},
+		{"equals", []string{"cert-exporter.io/scrape=true"}, true},
+		{"equals mismatch", []string{"cert-exporter.io/scrape=false"}, false},
+		{"not equals", []string{"cert-exporter.io/scrape!=false"}, true},
+		{"in", []string{"cert-exporter.io/team in (payments,search)"}, true},
+		{"notin", []string{"cert-exporter.io/team notin (payments,search)"}, false},
+		{"absent", []string{"!cert-exporter.io/skip"}, true},
+		{"absent mismatch", []string{"!cert-exporter.io/scrape"}, false},
+		{"all requirements", []string{"cert-exporter.io/scrape=true,cert-exporter.io/team=search"}, false},
+		{"any selector", []string{"cert-exporter.io/team=search", "cert-exporter.io/scrape=true"}, true},
+		{"invalid selector", []string{"cert-exporter.io/team in (payments"}, false},
+		{"url value", []string{"example.com/source=https://git.example.com/team/certs"}, true},
+		{"value with spaces", []string{"example.com/description == Front door cert (public)"}, true},
+		{"glob", []string{"example.com/source=https://git.example.com/*"}, true},
+		{"glob mismatch", []string{"example.com/source=https://github.com/*"}, false},
+		{"glob in", []string{"cert-exporter.io/team in (pay*, search)"}, true},
+		{"glob notin", []string{"cert-exporter.io/team notin (pay?ents)"}, false},
+		{"not equals absent", []string{"cert-exporter.io/skip!=true"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchAnnotations(tt.selectors, annotations); got != tt.want {
+				t.Errorf("matchAnnotations(%q) = %v, want %v", tt.selectors, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAnnotationSelectors(t *testing.T) {
+	valid := []string{"", "cert-manager.io/certificate-name", "!key", "key=", "key=https://example.com/a b", "key in (a, b*)", "key notin (a)"}
+	if err := ValidateAnnotationSelectors(valid); err != nil {
+		t.Errorf("Expected %q to be valid, got %v", valid, err)
+	}
+
+	for _, selector := range []string{"key in (a", "key)", "key in ()", "key like a", "a,,b", "!", "bad key=a", "-key"} {
+		if err := ValidateAnnotationSelectors([]string{selector}); err == nil {
+			t.Errorf("Expected an error for %q", selector)
+		}
+	}
+}
+
+func TestExpiryThresholds(t *testing.T) {
+	defaults := exporters.ExpiryThresholds{WarnBefore: 720 * time.Hour, CriticalBefore: 168 * time.Hour}
+
+	got, err := expiryThresholds(defaults, nil)
+	if err != nil || got != defaults {
+		t.Errorf("Expected the defaults without annotations, got %v, %v", got, err)
+	}
+
+	got, err = expiryThresholds(defaults, map[string]string{WarnBeforeAnnotation: "48h", CriticalBeforeAnnotation: "12h"})
+	want := exporters.ExpiryThresholds{WarnBefore: 48 * time.Hour, CriticalBefore: 12 * time.Hour}
+	if err != nil || got != want {
+		t.Errorf("Expected %v from the annotations, got %v, %v", want, got, err)
+	}
+
+	got, err = expiryThresholds(defaults, map[string]string{WarnBeforeAnnotation: "30 days", CriticalBeforeAnnotation: "12h"})
+	want = exporters.ExpiryThresholds{WarnBefore: 720 * time.Hour, CriticalBefore: 12 * time.Hour}
+	if err == nil || got != want {
+		t.Errorf("Expected %v and an error for an invalid annotation, got %v, %v", want, got, err)
+	}
+
+	got, err = expiryThresholds(defaults, map[string]string{WarnBeforeAnnotation: "72h"})
+	want = exporters.ExpiryThresholds{WarnBefore: 72 * time.Hour, CriticalBefore: 72 * time.Hour}
+	if err != nil || got != want {
+		t.Errorf("Expected %v for warn-before below the default critical-before, got %v, %v", want, got, err)
+	}
+
+	got, err = expiryThresholds(defaults, map[string]string{CriticalBeforeAnnotation: "1000h"})
+	want = exporters.ExpiryThresholds{WarnBefore: 1000 * time.Hour, CriticalBefore: 1000 * time.Hour}
+	if err != nil || got != want {
+		t.Errorf("Expected %v for critical-before above the default warn-before, got %v, %v", want, got, err)
+	}
+
+	got, err = expiryThresholds(defaults, map[string]string{WarnBeforeAnnotation: "24h", CriticalBeforeAnnotation: "48h"})
+	if err == nil || got != defaults {
+		t.Errorf("Expected the defaults and an error for swapped thresholds, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,83 @@
+package checkers
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ChainRoots configure the root certs the chains of kubernetes.io/tls secrets are verified against.  The system roots
+// are used unless a file or a configmap is set.
+type ChainRoots struct {
+	// File is a PEM bundle of root certs
+	File string
+	// ConfigMap is the namespace/name of a configmap whose data keys hold PEM bundles of root certs
+	ConfigMap string
+}
+
+// ValidateChainRoots checks the namespace/name reference of a chain roots configmap, which may be empty
+func ValidateChainRoots(configMap string) error {
+	if configMap == "" {
+		return nil
+	}
+	if _, _, ok := splitConfigMapRef(configMap); !ok {
+		return fmt.Errorf("invalid chain roots configmap %q: must be namespace/name", configMap)
+	}
+	return nil
+}
+
+// splitConfigMapRef splits a namespace/name configmap reference
+func splitConfigMapRef(ref string) (namespace, name string, ok bool) {
+	namespace, name, ok = strings.Cut(ref, "/")
+	return namespace, name, ok && namespace != "" && name != "" && !strings.Contains(name, "/")
+}
+
+// load reads the root certs.  The configmap is listed by name rather than fetched, so the checker only needs the
+// permission to list configmaps in its namespace.
+func (r ChainRoots) load(ctx context.Context, client kubernetes.Interface) (*x509.CertPool, error) {
+	if r.File == "" && r.ConfigMap == "" {
+		return x509.SystemCertPool()
+	}
+
+	pool := x509.NewCertPool()
+	if r.File != "" {
+		roots, err := os.ReadFile(r.File)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(roots) {
+			return nil, fmt.Errorf("no root certs found in %s", r.File)
+		}
+	}
+	if r.ConfigMap != "" {
+		namespace, name, ok := splitConfigMapRef(r.ConfigMap)
+		if !ok {
+			return nil, ValidateChainRoots(r.ConfigMap)
+		}
+		configMaps, err := client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, cm := range configMaps.Items {
+			if cm.Name != name {
+				continue
+			}
+			for _, roots := range cm.Data {
+				found = pool.AppendCertsFromPEM([]byte(roots)) || found
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no root certs found in configmap %s", r.ConfigMap)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,16 @@
+package checkers
+
+import "testing"
+
+func TestValidateChainRoots(t *testing.T) {
+	for _, configMap := range []string{"", "cert-manager/trust-bundle"} {
+		if err := ValidateChainRoots(configMap); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", configMap, err)
+		}
+	}
+	for _, configMap := range []string{"trust-bundle", "/trust-bundle", "cert-manager/", "a/b/c"} {
+		if err := ValidateChainRoots(configMap); err == nil {
+			t.Errorf("Expected %q to be invalid", configMap)
+		}
+	}
+}
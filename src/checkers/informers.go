@@ -0,0 +1,56 @@
+package checkers
+
+import (
+	"log/slog"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newInformerHandler adapts upsert and remove callbacks to a cache.ResourceEventHandler.  Updates
+// are delivered as an upsert of the new object and tombstones left behind by missed delete events
+// are unwrapped before remove is called.
+func newInformerHandler[T metav1.Object](upsert, remove func(T)) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if o, ok := obj.(T); ok {
+				upsert(o)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if o, ok := newObj.(T); ok {
+				upsert(o)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			o, ok := obj.(T)
+			if !ok {
+				slog.Warn("Ignoring delete event for unexpected object", "object", obj)
+				return
+			}
+			remove(o)
+		},
+	}
+}
+
+// storesContain returns the first object found under key in any of the provided stores.  It is
+// used to tell whether an object that left one informer is still selected by another.
+func storesContain(stores []cache.Store, key string) (interface{}, bool) {
+	for _, store := range stores {
+		obj, exists, err := store.GetByKey(key)
+		if err == nil && exists {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+// informerRegistration is an event handler added to a shared informer, kept to remove it again once the
+// checker stops.
+type informerRegistration struct {
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
+}
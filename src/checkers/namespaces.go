@@ -0,0 +1,88 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// namespaceSelector decides which namespaces a checker scans.  namespaces are names or glob patterns such as team-*,
+// with "" standing for every namespace, and namespaces matching one of the exclude patterns are never scanned.  If
+// labelSelectors are set only namespaces matching one of them are scanned.
+type namespaceSelector struct {
+	namespaces     []string
+	exclude        []string
+	labelSelectors []string
+}
+
+// discovers reports whether the namespaces to scan have to be listed because they are not given by name
+func (s namespaceSelector) discovers() bool {
+	if len(s.labelSelectors) > 0 || len(s.exclude) > 0 {
+		return true
+	}
+	return slices.ContainsFunc(s.namespaces, isNamespacePattern)
+}
+
+// selects reports whether a namespace matches the namespaces and none of the exclude patterns.  Label selectors are
+// not checked.
+func (s namespaceSelector) selects(namespace string) bool {
+	for _, pattern := range s.exclude {
+		if matchNamespace(pattern, namespace) {
+			return false
+		}
+	}
+	for _, pattern := range s.namespaces {
+		if pattern == metav1.NamespaceAll || matchNamespace(pattern, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the namespaces to scan, sorted.  Unless the selector discovers them these are the configured
+// namespaces.  Otherwise the namespaces are listed page by page, once per label selector, and those that are not
+// selected are dropped.  If some lists fail the namespaces found by the others are returned with the errors.
+func (s namespaceSelector) resolve(ctx context.Context, client kubernetes.Interface, pageSize int64) ([]string, error) {
+	if !s.discovers() {
+		return s.namespaces, nil
+	}
+
+	var namespaces []string
+	var errs []error
+	for _, options := range labelSelectorOptions(s.labelSelectors) {
+		err := listPages(ctx, options, pageSize, client.CoreV1().Namespaces().List, func(nss *corev1.NamespaceList) {
+			for _, ns := range nss.Items {
+				if s.selects(ns.Name) {
+					slog.Info("Adding namespace to check", "namespace", ns.Name)
+					namespaces = append(namespaces, ns.Name)
+				}
+			}
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listing namespaces matching %q: %w", options.LabelSelector, err))
+		}
+	}
+
+	slices.Sort(namespaces)
+	return slices.Compact(namespaces), errors.Join(errs...)
+}
+
+// isNamespacePattern reports whether a configured namespace is a glob pattern rather than a name
+func isNamespacePattern(namespace string) bool {
+	return strings.ContainsAny(namespace, `*?[\`)
+}
+
+// matchNamespace reports whether a namespace matches a name or glob pattern.  Patterns are validated with the config,
+// so a malformed pattern simply does not match.
+func matchNamespace(pattern, namespace string) bool {
+	matched, err := filepath.Match(pattern, namespace)
+	return err == nil && matched
+}
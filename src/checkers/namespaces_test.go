@@ -0,0 +1,126 @@
+package checkers
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
+)
+
+func TestNamespaceSelector_Selects(t *testing.T) {
+	tests := []struct {
+		name      string
+		selector  namespaceSelector
+		namespace string
+		want      bool
+	}{
+		{"all namespaces", namespaceSelector{namespaces: []string{""}}, "default", true},
+		{"named namespace", namespaceSelector{namespaces: []string{"default"}}, "default", true},
+		{"other namespace", namespaceSelector{namespaces: []string{"default"}}, "kube-system", false},
+		{"pattern", namespaceSelector{namespaces: []string{"team-*"}}, "team-a", true},
+		{"pattern mismatch", namespaceSelector{namespaces: []string{"team-*"}}, "default", false},
+		{"excluded name", namespaceSelector{namespaces: []string{""}, exclude: []string{"kube-system"}}, "kube-system", false},
+		{"excluded pattern", namespaceSelector{namespaces: []string{"team-*"}, exclude: []string{"team-legacy-*"}}, "team-legacy-a", false},
+		{"not excluded", namespaceSelector{namespaces: []string{""}, exclude: []string{"kube-*"}}, "default", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.selects(tt.namespace); got != tt.want {
+				t.Errorf("selects(%q) = %v, want %v", tt.namespace, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceSelector_Discovers(t *testing.T) {
+	if (namespaceSelector{namespaces: []string{"default", "kube-system"}}).discovers() {
+		t.Error("Expected named namespaces not to be discovered")
+	}
+	if (namespaceSelector{namespaces: []string{""}}).discovers() {
+		t.Error("Expected all namespaces not to be discovered")
+	}
+	if !(namespaceSelector{namespaces: []string{"team-*"}}).discovers() {
+		t.Error("Expected a pattern to be discovered")
+	}
+	if !(namespaceSelector{namespaces: []string{""}, exclude: []string{"kube-system"}}).discovers() {
+		t.Error("Expected exclusions to be discovered")
+	}
+	if !(namespaceSelector{namespaces: []string{""}, labelSelectors: []string{"certs=true"}}).discovers() {
+		t.Error("Expected a label selector to be discovered")
+	}
+}
+
+func TestNamespaceSelector_Resolve(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"certs": "true"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"certs": "true"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "helm-releases", Labels: map[string]string{"certs": "true"}}},
+	)
+
+	tests := []struct {
+		name     string
+		selector namespaceSelector
+		want     []string
+	}{
+		{"named", namespaceSelector{namespaces: []string{"default", "missing"}}, []string{"default", "missing"}},
+		{"exclude", namespaceSelector{namespaces: []string{""}, exclude: []string{"kube-*", "helm-releases"}}, []string{"default", "team-a", "team-b"}},
+		{"pattern", namespaceSelector{namespaces: []string{"team-*", "default"}}, []string{"default", "team-a", "team-b"}},
+		{"label selector and exclude", namespaceSelector{namespaces: []string{""}, exclude: []string{"team-b"}, labelSelectors: []string{"certs=true", "certs"}}, []string{"helm-releases", "team-a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selector.resolve(context.Background(), client, 2)
+			if err != nil {
+				t.Fatalf("Failed to resolve namespaces: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected namespaces %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDiscoveringCheckers_Permissions(t *testing.T) {
+	namespaces := []string{"team-*"}
+	tests := []struct {
+		name        string
+		permissions []kubeclient.Permission
+		want        kubeclient.Permission
+	}{
+		{
+			"secrets",
+			NewSecretChecker(time.Hour, nil, nil, nil, nil, nil, namespaces, nil, nil, nil, nil, &exporters.SecretExporter{}, nil, 100, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{}).Permissions(false),
+			kubeclient.Permission{Verb: "list", Resource: "secrets", Namespace: metav1.NamespaceAll},
+		},
+		{
+			"configmaps",
+			NewConfigMapChecker(time.Hour, nil, nil, nil, nil, nil, namespaces, nil, nil, nil, &exporters.ConfigMapExporter{}, 100, 4, exporters.ExpiryThresholds{}).Permissions(),
+			kubeclient.Permission{Verb: "list", Resource: "configmaps", Namespace: metav1.NamespaceAll},
+		},
+		{
+			"certrequests",
+			NewCertRequestChecker(time.Hour, nil, nil, nil, namespaces, nil, nil, nil, &exporters.CertRequestExporter{}, exporters.ExpiryThresholds{}).Permissions(),
+			kubeclient.Permission{Verb: "list", Group: "cert-manager.io", Resource: "certificaterequests", Namespace: metav1.NamespaceAll},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespacesPermission := kubeclient.Permission{Verb: "list", Resource: "namespaces"}
+			if !slices.Contains(tt.permissions, namespacesPermission) || !slices.Contains(tt.permissions, tt.want) {
+				t.Errorf("Expected %v and %v when namespaces are discovered, got %v", namespacesPermission, tt.want, tt.permissions)
+			}
+		})
+	}
+}
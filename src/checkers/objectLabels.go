@@ -0,0 +1,59 @@
+package checkers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// annotationToMetricPrefix marks an entry of a label-to-metric allowlist that copies an annotation instead of a label
+const annotationToMetricPrefix = "annotation:"
+
+var invalidMetricLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// metricLabelName returns the prometheus label an entry of a label-to-metric allowlist is copied to.  Like
+// kube-state-metrics, labels become label_<key> and annotations annotation_<key>, with every character that is not
+// allowed in a prometheus label replaced by an underscore.
+func metricLabelName(entry string) string {
+	if key, ok := strings.CutPrefix(entry, annotationToMetricPrefix); ok {
+		return "annotation_" + invalidMetricLabelChars.ReplaceAllString(key, "_")
+	}
+	return "label_" + invalidMetricLabelChars.ReplaceAllString(entry, "_")
+}
+
+// ValidateLabelsToMetric checks a label-to-metric allowlist.  Entries are label keys, or annotation keys prefixed
+// with annotation:, and no two may be copied to the same prometheus label.
+func ValidateLabelsToMetric(entries []string) error {
+	var errs []error
+	seen := map[string]string{}
+	for _, entry := range entries {
+		if strings.TrimPrefix(entry, annotationToMetricPrefix) == "" {
+			errs = append(errs, fmt.Errorf("invalid label-to-metric entry %q: key must not be empty", entry))
+			continue
+		}
+		name := metricLabelName(entry)
+		if other, ok := seen[name]; ok {
+			errs = append(errs, fmt.Errorf("label-to-metric entries %q and %q are both copied to %s", other, entry, name))
+			continue
+		}
+		seen[name] = entry
+	}
+	return errors.Join(errs...)
+}
+
+// objectMetricLabels returns the prometheus label names and values an object's labels and annotations are copied to.
+// Labels and annotations the object does not have are copied as empty values.
+func objectMetricLabels(entries []string, object metav1.Object) (names, values []string) {
+	for _, entry := range entries {
+		names = append(names, metricLabelName(entry))
+		if key, ok := strings.CutPrefix(entry, annotationToMetricPrefix); ok {
+			values = append(values, object.GetAnnotations()[key])
+		} else {
+			values = append(values, object.GetLabels()[entry])
+		}
+	}
+	return names, values
+}
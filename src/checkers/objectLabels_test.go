@@ -0,0 +1,50 @@
+package checkers
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectMetricLabels(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Labels:      map[string]string{"team": "payments", "app.kubernetes.io/part-of": "shop"},
+		Annotations: map[string]string{"example.com/owner": "alice", "team": "ignored"},
+	}}
+
+	names, values := objectMetricLabels([]string{"app.kubernetes.io/part-of", "team", "annotation:example.com/owner", "missing"}, secret)
+
+	wantNames := []string{"label_app_kubernetes_io_part_of", "label_team", "annotation_example_com_owner", "label_missing"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("Expected names %v, got %v", wantNames, names)
+	}
+	wantValues := []string{"shop", "payments", "alice", ""}
+	if !slices.Equal(values, wantValues) {
+		t.Errorf("Expected values %v, got %v", wantValues, values)
+	}
+}
+
+func TestValidateLabelsToMetric(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"labels and annotations", []string{"team", "annotation:team", "app.kubernetes.io/name"}, false},
+		{"empty label", []string{""}, true},
+		{"empty annotation", []string{"annotation:"}, true},
+		{"same prometheus label", []string{"app.kubernetes.io/name", "app_kubernetes_io/name"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLabelsToMetric(tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLabelsToMetric(%q) error = %v, wantErr %v", tt.entries, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"strings"
@@ -14,7 +15,6 @@ import (
 	"log/slog"
 
 	"github.com/joe-elliott/cert-exporter/src/exporters"
-	"github.com/joe-elliott/cert-exporter/src/metrics"
 )
 
 // SecretsManagerClientFactory creates a Secrets Manager client for testing
@@ -56,37 +56,46 @@ func NewAwsCheckerWithClientFactory(awsAccount, awsRegion, awsKeySubString strin
 	}
 }
 
-// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.
-func (p *PeriodicAwsChecker) StartChecking() {
+// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicAwsChecker) StartChecking(ctx context.Context) {
 	periodChannel := time.Tick(p.period)
 	for {
 		slog.Info("AWS Checker: Begin periodic check")
-		p.exporter.ResetMetrics()
+		p.exporter.BeginScan()
 
-		if err := p.checkSecrets(); err != nil {
+		scanCtx, cancel := newScanContext(ctx, p.period)
+		err := p.checkSecrets(scanCtx)
+		if err != nil && ctx.Err() == nil {
 			slog.Error("Error checking secrets", "error", err)
-			metrics.ErrorTotal.Inc()
+		}
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
 		}
 
-		<-periodChannel
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
 	}
 }
 
 // checkSecrets performs one round of secret checking - extracted for testability
-func (p *PeriodicAwsChecker) checkSecrets() error {
+func (p *PeriodicAwsChecker) checkSecrets(ctx context.Context) error {
 	// Create AWS client
 	client, err := p.clientFactory(p.awsRegion)
 	if err != nil {
 		slog.Error("Error initializing AWS client", "error", err)
-		metrics.ErrorTotal.Inc()
+		p.exporter.ScanError(exporters.ReasonAPIError)
 		return err
 	}
 
 	// Process each secret
 	for _, secretName := range p.awsSecrets {
-		if err := p.processSecret(client, secretName); err != nil {
+		if err := p.processSecret(ctx, client, secretName); err != nil {
 			slog.Error("Error processing secret", "secret", secretName, "error", err)
-			metrics.ErrorTotal.Inc()
 			// Continue processing other secrets
 		}
 	}
@@ -95,15 +104,17 @@ func (p *PeriodicAwsChecker) checkSecrets() error {
 }
 
 // processSecret retrieves and processes a single secret - extracted for testability
-func (p *PeriodicAwsChecker) processSecret(client secretsmanageriface.SecretsManagerAPI, secretName string) error {
+func (p *PeriodicAwsChecker) processSecret(ctx context.Context, client secretsmanageriface.SecretsManagerAPI, secretName string) error {
 	slog.Info("Getting secret " + secretName + " from AWS Secrets Manager")
 
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String("arn:aws:secretsmanager:" + p.awsRegion + ":" + p.awsAccount + ":secret:" + secretName),
 	}
 
-	secretValue, err := client.GetSecretValue(input)
+	p.exporter.ObjectScanned()
+	secretValue, err := client.GetSecretValueWithContext(ctx, input)
 	if err != nil {
+		p.exporter.ScanError(exporters.ReasonObjectError)
 		return err
 	}
 
@@ -116,6 +127,7 @@ func (p *PeriodicAwsChecker) processSecret(client secretsmanageriface.SecretsMan
 
 	var secretMap map[string]interface{}
 	if err := json.Unmarshal([]byte(secretString), &secretMap); err != nil {
+		p.exporter.ScanError(exporters.ReasonParseError)
 		return err
 	}
 
@@ -124,7 +136,7 @@ func (p *PeriodicAwsChecker) processSecret(client secretsmanageriface.SecretsMan
 		if strings.Contains(key, p.awsKeySubString) {
 			if err := p.processCertificateKey(secretName, key, value); err != nil {
 				slog.Error("Error processing certificate key", "key", key, "secret", secretName, "error", err)
-				metrics.ErrorTotal.Inc()
+				p.exporter.ScanError(exporters.ReasonParseError)
 				// Continue processing other keys
 			}
 		}
@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/joe-elliott/cert-exporter/internal/testutil"
@@ -23,7 +25,7 @@ type mockSecretsManagerClient struct {
 	err     error
 }
 
-func (m *mockSecretsManagerClient) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+func (m *mockSecretsManagerClient) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -149,7 +151,7 @@ func TestPeriodicAwsChecker_ProcessSecret_Success(t *testing.T) {
 	)
 
 	// Process the secret
-	err := checker.processSecret(mockClient, "test-secret")
+	err := checker.processSecret(context.Background(), mockClient, "test-secret")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -216,7 +218,7 @@ func TestPeriodicAwsChecker_ProcessSecret_RawPEM(t *testing.T) {
 	)
 
 	// Process the secret
-	err := checker.processSecret(mockClient, "raw-secret")
+	err := checker.processSecret(context.Background(), mockClient, "raw-secret")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -285,7 +287,7 @@ func TestPeriodicAwsChecker_ProcessSecret_KeyFiltering(t *testing.T) {
 		},
 	)
 
-	err := checker.processSecret(mockClient, "filter-test")
+	err := checker.processSecret(context.Background(), mockClient, "filter-test")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -340,10 +342,15 @@ func TestPeriodicAwsChecker_ProcessSecret_ClientError(t *testing.T) {
 		},
 	)
 
-	err := checker.processSecret(mockClient, "error-secret")
+	exporter.BeginScan()
+	err := checker.processSecret(context.Background(), mockClient, "error-secret")
 	if err == nil {
 		t.Error("Expected error when client returns error")
 	}
+	if exporter.ScanFailed() {
+		t.Error("Expected a secret that could not be fetched not to fail the scan")
+	}
+	exporter.EndScan()
 }
 
 func TestPeriodicAwsChecker_ProcessSecret_InvalidJSON(t *testing.T) {
@@ -366,7 +373,7 @@ func TestPeriodicAwsChecker_ProcessSecret_InvalidJSON(t *testing.T) {
 		},
 	)
 
-	err := checker.processSecret(mockClient, "bad-json")
+	err := checker.processSecret(context.Background(), mockClient, "bad-json")
 	if err == nil {
 		t.Error("Expected error when secret contains invalid JSON")
 	}
@@ -415,7 +422,7 @@ func TestPeriodicAwsChecker_CheckSecrets_MultipleSecrets(t *testing.T) {
 		},
 	)
 
-	err := checker.checkSecrets()
+	err := checker.checkSecrets(context.Background())
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -461,8 +468,60 @@ func TestPeriodicAwsChecker_CheckSecrets_ClientFactoryError(t *testing.T) {
 		},
 	)
 
-	err := checker.checkSecrets()
+	err := checker.checkSecrets(context.Background())
 	if err == nil {
 		t.Error("Expected error when client factory fails")
 	}
 }
+
+func TestPeriodicAwsChecker_StartChecking_CountsErrorOnce(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	health := exporters.NewScanHealth("aws", "aws")
+	exporter := &exporters.AwsExporter{}
+	exporter.SetScanHealth(health)
+	checker := NewAwsCheckerWithClientFactory(
+		"123456789012",
+		"us-east-1",
+		".pem",
+		[]string{"test-secret"},
+		time.Hour,
+		exporter,
+		func(region string) (secretsmanageriface.SecretsManagerAPI, error) {
+			return nil, errors.New("failed to create client")
+		},
+	)
+
+	errorTotal := func() float64 {
+		mfs, err := testRegistry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+		for _, mf := range mfs {
+			if mf.GetName() == "cert_exporter_error_total" {
+				return mf.GetMetric()[0].GetCounter().GetValue()
+			}
+		}
+		return 0
+	}
+	before := errorTotal()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.StartChecking(ctx)
+		close(done)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); !health.Scanned() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// error_total is shared by every test, so only the increase is checked
+	if got := errorTotal() - before; got != 1 {
+		t.Errorf("Expected the failed scan to be counted once in error_total, got %v", got)
+	}
+	exporter.Clear()
+}
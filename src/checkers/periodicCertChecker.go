@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -75,26 +76,33 @@ func NewCertChecker(period time.Duration, includeCertGlobs, excludeCertGlobs []s
 	}
 }
 
-// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.
-func (p *PeriodicCertChecker) StartChecking() {
+// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicCertChecker) StartChecking(ctx context.Context) {
 	periodChannel := time.Tick(p.period)
 
 	for {
 		slog.Info("Begin periodic check")
 
-		p.exporter.ResetMetrics()
+		p.exporter.BeginScan()
 
 		for _, match := range p.getMatches() {
 			slog.Info("Publishing node metrics", "nodeName", p.nodeName, "match", match)
 
+			p.exporter.ObjectScanned()
 			err := p.exporter.ExportMetrics(match, p.nodeName)
 			if err != nil {
-				metrics.ErrorTotal.Inc()
+				p.exporter.ScanError(exporters.ReasonParseError)
 				slog.Error("Error exporting metrics", "match", match, "error", err)
 			}
 		}
 
-		<-periodChannel
+		endScan(ctx, p.exporter)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
 	}
 }
 
@@ -103,7 +111,7 @@ func (p *PeriodicCertChecker) getMatches() []string {
 	for _, includeGlob := range p.includeCertGlobs {
 		matches, err := includeGlob.Apply()
 		if err != nil {
-			metrics.ErrorTotal.Inc()
+			p.exporter.ScanError(exporters.ReasonGlobError)
 			slog.Error("Glob failed", "glob", includeGlob, "error", err)
 			continue
 		}
@@ -116,7 +124,7 @@ func (p *PeriodicCertChecker) getMatches() []string {
 	for _, excludeGlob := range p.excludeCertGlobs {
 		matches, err := excludeGlob.Apply()
 		if err != nil {
-			metrics.ErrorTotal.Inc()
+			p.exporter.ScanError(exporters.ReasonGlobError)
 			slog.Error("Glob failed", "glob", excludeGlob, "error", err)
 			continue
 		}
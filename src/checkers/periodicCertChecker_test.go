@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -143,7 +144,9 @@ func TestPeriodicCertChecker_StartChecking(t *testing.T) {
 		done <- true
 	}()
 
-	go checker.StartChecking()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.StartChecking(ctx)
 
 	// Wait for checker to run at least once
 	time.Sleep(150 * time.Millisecond)
@@ -198,7 +201,9 @@ func TestPeriodicCertChecker_ErrorHandling(t *testing.T) {
 	checker := NewCertChecker(100*time.Millisecond, includeGlobs, []string{}, nodeName, &exporters.CertExporter{})
 
 	// Start checking
-	go checker.StartChecking()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.StartChecking(ctx)
 
 	// Poll for metrics with timeout (allows time for async processing)
 	maxWait := 500 * time.Millisecond
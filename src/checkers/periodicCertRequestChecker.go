@@ -9,47 +9,62 @@ import (
 	cmClientSet "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned/typed/certmanager/v1"
 	"log/slog"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/joe-elliott/cert-exporter/src/exporters"
-	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
 )
 
 // PeriodicCertRequestChecker is an object designed to check for files on disk at a regular interval
 type PeriodicCertRequestChecker struct {
 	period              time.Duration
 	labelSelectors      []string
-	kubeconfigPath      string
+	client              cmClientSet.CertmanagerV1Interface
+	namespaceClient     kubernetes.Interface
 	annotationSelectors []string
+	labelsToMetric      []string
 	namespaces          []string
+	excludeNamespaces   []string
 	exporter            *exporters.CertRequestExporter
+	thresholds          exporters.ExpiryThresholds
 }
 
-// NewCertRequestChecker is a factory method that returns a new PeriodicCertRequestChecker
-func NewCertRequestChecker(period time.Duration, labelSelectors, annotationSelectors, namespaces []string, kubeconfigPath string, e *exporters.CertRequestExporter) *PeriodicCertRequestChecker {
+// NewCertRequestChecker is a factory method that returns a new PeriodicCertRequestChecker.  namespaceClient is used to
+// discover the namespaces matching patterns and exclusions.
+func NewCertRequestChecker(period time.Duration, labelSelectors, annotationSelectors, labelsToMetric, namespaces, excludeNamespaces []string, client cmClientSet.CertmanagerV1Interface, namespaceClient kubernetes.Interface, e *exporters.CertRequestExporter, thresholds exporters.ExpiryThresholds) *PeriodicCertRequestChecker {
 	return &PeriodicCertRequestChecker{
 		period:              period,
 		labelSelectors:      labelSelectors,
 		annotationSelectors: annotationSelectors,
+		labelsToMetric:      labelsToMetric,
 		namespaces:          namespaces,
-		kubeconfigPath:      kubeconfigPath,
+		excludeNamespaces:   excludeNamespaces,
+		client:              client,
+		namespaceClient:     namespaceClient,
 		exporter:            e,
+		thresholds:          thresholds,
 	}
 }
 
-// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.
-func (p *PeriodicCertRequestChecker) StartChecking() {
-	config, err := clientcmd.BuildConfigFromFlags("", p.kubeconfigPath)
-	if err != nil {
-		slog.Error("Error building kubeconfig", "error", err)
-	}
+// namespaceSelector returns the selector for the namespaces the checker scans
+func (p *PeriodicCertRequestChecker) namespaceSelector() namespaceSelector {
+	return namespaceSelector{namespaces: p.namespaces, exclude: p.excludeNamespaces}
+}
 
-	// creates the certmanager client
-	certmanagerClient, err := cmClientSet.NewForConfig(config)
-	if err != nil {
-		slog.Error("certmanager.NewForConfig failed", "error", err)
+// Permissions returns the requests the checker makes against the API server.  CertRequests in discovered namespaces
+// can only be checked once they are found.
+func (p *PeriodicCertRequestChecker) Permissions() []kubeclient.Permission {
+	if p.namespaceSelector().discovers() {
+		return []kubeclient.Permission{
+			{Verb: "list", Resource: "namespaces"},
+			{Verb: "list", Group: "cert-manager.io", Resource: "certificaterequests", Namespace: metav1.NamespaceAll},
+		}
 	}
+	return namespacedPermissions("list", "cert-manager.io", "certificaterequests", p.namespaces)
+}
 
+// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicCertRequestChecker) StartChecking(ctx context.Context) {
 	periodChannel := time.Tick(p.period)
 	if strings.Join(p.namespaces, ", ") != "" {
 		slog.Info("Scan certrequests", "target", strings.Join(p.namespaces, ", "))
@@ -57,28 +72,35 @@ func (p *PeriodicCertRequestChecker) StartChecking() {
 	for {
 		slog.Info("Begin periodic check")
 
-		p.exporter.ResetMetrics()
+		p.exporter.BeginScan()
+		scanCtx, cancel := newScanContext(ctx, p.period)
 
 		var certrequests []cmapiv1.CertificateRequest
 
-		for _, ns := range p.namespaces {
+		namespaces, err := p.namespaceSelector().resolve(scanCtx, p.namespaceClient, 0)
+		if err != nil {
+			slog.Error("Error requesting namespaces", "error", err)
+			p.exporter.ScanError(exporters.ReasonAPIError)
+		}
+
+		for _, ns := range namespaces {
 			var c *cmapiv1.CertificateRequestList
 
 			if len(p.labelSelectors) > 0 {
 				for _, labelSelector := range p.labelSelectors {
-					c, err = certmanagerClient.CertificateRequests(ns).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+					c, err = p.client.CertificateRequests(ns).List(scanCtx, metav1.ListOptions{LabelSelector: labelSelector})
 					if err != nil {
 						slog.Error("Error requesting certrequest", "error", err)
-						metrics.ErrorTotal.Inc()
+						p.exporter.ScanError(exporters.ReasonAPIError)
 						continue
 					}
 					certrequests = append(certrequests, c.Items...)
 				}
 			} else {
-				c, err = certmanagerClient.CertificateRequests(ns).List(context.TODO(), metav1.ListOptions{})
+				c, err = p.client.CertificateRequests(ns).List(scanCtx, metav1.ListOptions{})
 				if err != nil {
 					slog.Error("Error requesting certrequest", "error", err)
-					metrics.ErrorTotal.Inc()
+					p.exporter.ScanError(exporters.ReasonAPIError)
 					continue
 				}
 				certrequests = append(certrequests, c.Items...)
@@ -86,6 +108,7 @@ func (p *PeriodicCertRequestChecker) StartChecking() {
 		}
 
 		for _, certrequest := range certrequests {
+			p.exporter.ObjectScanned()
 			include := false
 			for _, condition := range certrequest.Status.Conditions {
 				// Include only certrequests that issued a certificate successfully
@@ -101,32 +124,40 @@ func (p *PeriodicCertRequestChecker) StartChecking() {
 
 			slog.Info("Reviewing certrequest", "name", certrequest.GetName(), "namespace", certrequest.GetNamespace())
 
-			if len(p.annotationSelectors) > 0 {
-				matches := false
-				annotations := certrequest.GetAnnotations()
-				for _, selector := range p.annotationSelectors {
-					_, ok := annotations[selector]
-					if ok {
-						matches = true
-						break
-					}
-				}
-
-				if !matches {
-					continue
-				}
+			if !matchAnnotations(p.annotationSelectors, certrequest.GetAnnotations()) {
+				continue
 			}
 			slog.Info("Annotations matched. Parsing certrequest.")
 
+			thresholds, err := expiryThresholds(p.thresholds, certrequest.GetAnnotations())
+			if err != nil {
+				slog.Error("Error reading expiry thresholds", "name", certrequest.Name, "namespace", certrequest.Namespace, "error", err)
+				p.exporter.ScanError(exporters.ReasonParseError)
+			}
+
 			slog.Info("Publishing metrics", "name", certrequest.Name, "namespace", certrequest.Namespace)
-			err = p.exporter.ExportMetrics(certrequest.Status.Certificate, certrequest.Name, certrequest.Namespace)
+			err = p.exporter.ExportMetrics(certrequest.Status.Certificate, certrequest.Name, certrequest.Namespace, thresholds)
 			if err != nil {
 				slog.Error("Error exporting certrequest", "error", err)
-				metrics.ErrorTotal.Inc()
+				p.exporter.ScanError(exporters.ReasonParseError)
 			}
+			if len(p.labelsToMetric) > 0 {
+				names, values := objectMetricLabels(p.labelsToMetric, &certrequest)
+				p.exporter.ExportLabels(names, values, certrequest.Name, certrequest.Namespace)
+			}
+
+		}
 
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
 		}
 
-		<-periodChannel
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
 	}
 }
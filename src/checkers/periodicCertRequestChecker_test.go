@@ -1,9 +1,12 @@
 package checkers
 
 import (
+	cmfake "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned/fake"
 	"testing"
 	"time"
 
+	"k8s.io/client-go/kubernetes/fake"
+
 	"github.com/joe-elliott/cert-exporter/src/exporters"
 )
 
@@ -12,10 +15,12 @@ func TestNewCertRequestChecker(t *testing.T) {
 	labelSelectors := []string{"app=test", "env=prod"}
 	annotationSelectors := []string{"cert-manager.io/certificate-name"}
 	namespaces := []string{"default", "kube-system"}
-	kubeconfigPath := "/path/to/kubeconfig"
+	excludeNamespaces := []string{"team-*"}
+	client := cmfake.NewSimpleClientset().CertmanagerV1()
+	namespaceClient := fake.NewSimpleClientset()
 	exporter := &exporters.CertRequestExporter{}
 
-	checker := NewCertRequestChecker(period, labelSelectors, annotationSelectors, namespaces, kubeconfigPath, exporter)
+	checker := NewCertRequestChecker(period, labelSelectors, annotationSelectors, nil, namespaces, excludeNamespaces, client, namespaceClient, exporter, exporters.ExpiryThresholds{})
 
 	if checker == nil {
 		t.Fatal("Expected NewCertRequestChecker to return non-nil checker")
@@ -37,8 +42,16 @@ func TestNewCertRequestChecker(t *testing.T) {
 		t.Errorf("Expected %d namespaces, got %d", len(namespaces), len(checker.namespaces))
 	}
 
-	if checker.kubeconfigPath != kubeconfigPath {
-		t.Errorf("Expected kubeconfigPath '%s', got '%s'", kubeconfigPath, checker.kubeconfigPath)
+	if len(checker.excludeNamespaces) != len(excludeNamespaces) {
+		t.Errorf("Expected %d excludeNamespaces, got %d", len(excludeNamespaces), len(checker.excludeNamespaces))
+	}
+
+	if checker.client != client {
+		t.Error("Expected client to match provided client")
+	}
+
+	if checker.namespaceClient != namespaceClient {
+		t.Error("Expected namespace client to match provided client")
 	}
 
 	if checker.exporter != exporter {
@@ -47,7 +60,7 @@ func TestNewCertRequestChecker(t *testing.T) {
 }
 
 func TestNewCertRequestChecker_EmptyParameters(t *testing.T) {
-	checker := NewCertRequestChecker(time.Second, []string{}, []string{}, []string{}, "", nil)
+	checker := NewCertRequestChecker(time.Second, []string{}, []string{}, nil, []string{}, nil, nil, nil, nil, exporters.ExpiryThresholds{})
 
 	if checker == nil {
 		t.Fatal("Expected NewCertRequestChecker to return non-nil checker")
@@ -75,9 +88,13 @@ func TestNewCertRequestChecker_MultipleValues(t *testing.T) {
 		10*time.Second,
 		labelSelectors,
 		annotationSelectors,
+		nil,
 		namespaces,
-		"/etc/kubeconfig",
+		nil,
+		nil,
+		nil,
 		&exporters.CertRequestExporter{},
+		exporters.ExpiryThresholds{},
 	)
 
 	if len(checker.labelSelectors) != len(labelSelectors) {
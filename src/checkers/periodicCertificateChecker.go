@@ -0,0 +1,111 @@
+package checkers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	cmapiv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmClientSet "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned/typed/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
+)
+
+// PeriodicCertificateChecker is an object designed to check the status of cert-manager Certificates at a regular interval
+type PeriodicCertificateChecker struct {
+	period              time.Duration
+	labelSelectors      []string
+	client              cmClientSet.CertmanagerV1Interface
+	annotationSelectors []string
+	namespaces          []string
+	exporter            *exporters.CertificateExporter
+}
+
+// NewCertificateChecker is a factory method that returns a new PeriodicCertificateChecker
+func NewCertificateChecker(period time.Duration, labelSelectors, annotationSelectors, namespaces []string, client cmClientSet.CertmanagerV1Interface, e *exporters.CertificateExporter) *PeriodicCertificateChecker {
+	return &PeriodicCertificateChecker{
+		period:              period,
+		labelSelectors:      labelSelectors,
+		annotationSelectors: annotationSelectors,
+		namespaces:          namespaces,
+		client:              client,
+		exporter:            e,
+	}
+}
+
+// Permissions returns the requests the checker makes against the API server
+func (p *PeriodicCertificateChecker) Permissions() []kubeclient.Permission {
+	return namespacedPermissions("list", "cert-manager.io", "certificates", p.namespaces)
+}
+
+// StartChecking starts the periodic certificate check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicCertificateChecker) StartChecking(ctx context.Context) {
+	periodChannel := time.Tick(p.period)
+	if strings.Join(p.namespaces, ", ") != "" {
+		slog.Info("Scan certificates", "target", strings.Join(p.namespaces, ", "))
+	}
+	for {
+		slog.Info("Begin periodic check")
+
+		p.exporter.BeginScan()
+		scanCtx, cancel := newScanContext(ctx, p.period)
+		p.checkCertificates(scanCtx, p.client)
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
+	}
+}
+
+// checkCertificates exports the status of every selected certificate once
+func (p *PeriodicCertificateChecker) checkCertificates(ctx context.Context, certmanagerClient cmClientSet.CertmanagerV1Interface) {
+	var certificates []cmapiv1.Certificate
+
+	for _, ns := range p.namespaces {
+		if len(p.labelSelectors) > 0 {
+			for _, labelSelector := range p.labelSelectors {
+				c, err := certmanagerClient.Certificates(ns).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+				if err != nil {
+					slog.Error("Error requesting certificates", "error", err)
+					p.exporter.ScanError(exporters.ReasonAPIError)
+					continue
+				}
+				certificates = append(certificates, c.Items...)
+			}
+		} else {
+			c, err := certmanagerClient.Certificates(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				slog.Error("Error requesting certificates", "error", err)
+				p.exporter.ScanError(exporters.ReasonAPIError)
+				continue
+			}
+			certificates = append(certificates, c.Items...)
+		}
+	}
+
+	for i := range certificates {
+		certificate := &certificates[i]
+		p.exporter.ObjectScanned()
+		slog.Info("Reviewing certificate", "name", certificate.GetName(), "namespace", certificate.GetNamespace())
+
+		if !matchAnnotations(p.annotationSelectors, certificate.GetAnnotations()) {
+			continue
+		}
+		slog.Info("Annotations matched. Parsing certificate.")
+
+		slog.Info("Publishing metrics", "name", certificate.Name, "namespace", certificate.Namespace)
+		if p.exporter.ExportMetrics(certificate) {
+			slog.Warn("Certificate renewal is overdue", "name", certificate.Name, "namespace", certificate.Namespace, "renewal_time", certificate.Status.RenewalTime.Time)
+		}
+	}
+}
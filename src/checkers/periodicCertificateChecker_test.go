@@ -0,0 +1,100 @@
+package checkers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapiv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cmfake "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned/fake"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestPeriodicCertificateChecker_CheckCertificates(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	now := time.Now()
+	failedAttempts := 3
+	newCertificate := func(name string, renewalTime time.Time, ready cmmeta.ConditionStatus, annotations map[string]string) *cmapiv1.Certificate {
+		return &cmapiv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+			Spec:       cmapiv1.CertificateSpec{SecretName: name + "-tls"},
+			Status: cmapiv1.CertificateStatus{
+				Conditions:  []cmapiv1.CertificateCondition{{Type: cmapiv1.CertificateConditionReady, Status: ready}},
+				NotAfter:    &metav1.Time{Time: now.Add(30 * 24 * time.Hour)},
+				RenewalTime: &metav1.Time{Time: renewalTime},
+			},
+		}
+	}
+
+	healthy := newCertificate("healthy", now.Add(10*24*time.Hour), cmmeta.ConditionTrue, map[string]string{"team": "a"})
+	stuck := newCertificate("stuck", now.Add(-24*time.Hour), cmmeta.ConditionFalse, map[string]string{"team": "b"})
+	stuck.Status.Conditions = append(stuck.Status.Conditions, cmapiv1.CertificateCondition{Type: cmapiv1.CertificateConditionIssuing, Status: cmmeta.ConditionTrue})
+	stuck.Status.FailedIssuanceAttempts = &failedAttempts
+	ignored := newCertificate("ignored", now.Add(-24*time.Hour), cmmeta.ConditionTrue, nil)
+
+	client := cmfake.NewSimpleClientset(healthy, stuck, ignored)
+
+	exporter := &exporters.CertificateExporter{}
+	exporter.ResetMetrics()
+	checker := NewCertificateChecker(time.Hour, nil, []string{"team"}, []string{""}, nil, exporter)
+
+	exporter.BeginScan()
+	checker.checkCertificates(context.Background(), client.CertmanagerV1())
+	exporter.EndScan()
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := map[string]map[string]float64{}
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if values[labels["certificate"]] == nil {
+				values[labels["certificate"]] = map[string]float64{}
+			}
+			values[labels["certificate"]][mf.GetName()] = metric.GetGauge().GetValue()
+		}
+	}
+
+	if _, ok := values["ignored"]; ok {
+		t.Error("Expected certificate without matching annotation to be skipped")
+	}
+
+	if v := values["healthy"]["cert_exporter_certificate_ready"]; v != 1 {
+		t.Errorf("Expected healthy certificate to be ready, got %v", v)
+	}
+	if v := values["healthy"]["cert_exporter_certificate_renewal_overdue"]; v != 0 {
+		t.Errorf("Expected healthy certificate renewal not to be overdue, got %v", v)
+	}
+	if v := values["healthy"]["cert_exporter_certificate_renewal_in_seconds"]; v <= 0 {
+		t.Errorf("Expected positive renewal seconds for healthy certificate, got %v", v)
+	}
+
+	if v := values["stuck"]["cert_exporter_certificate_ready"]; v != 0 {
+		t.Errorf("Expected stuck certificate not to be ready, got %v", v)
+	}
+	if v := values["stuck"]["cert_exporter_certificate_issuing"]; v != 1 {
+		t.Errorf("Expected stuck certificate to be issuing, got %v", v)
+	}
+	if v := values["stuck"]["cert_exporter_certificate_renewal_overdue"]; v != 1 {
+		t.Errorf("Expected stuck certificate renewal to be overdue, got %v", v)
+	}
+	if v := values["stuck"]["cert_exporter_certificate_failed_issuance_attempts"]; v != 3 {
+		t.Errorf("Expected 3 failed issuance attempts, got %v", v)
+	}
+	if v := values["stuck"]["cert_exporter_certificate_expires_in_seconds"]; v <= 0 {
+		t.Errorf("Expected positive expiry seconds, got %v", v)
+	}
+}
@@ -10,53 +10,68 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/joe-elliott/cert-exporter/src/exporters"
-	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
 )
 
 // PeriodicConfigMapChecker is an object designed to check for files on disk at a regular interval
 type PeriodicConfigMapChecker struct {
 	period                     time.Duration
 	labelSelectors             []string
-	kubeconfigPath             string
+	client                     kubernetes.Interface
 	annotationSelectors        []string
+	labelsToMetric             []string
 	namespaces                 []string
 	exporter                   *exporters.ConfigMapExporter
+	thresholds                 exporters.ExpiryThresholds
 	includeConfigMapsDataGlobs []string
 	excludeConfigMapsDataGlobs []string
 	nsLabelSelector            []string
+	excludeNamespaces          []string
+	pageSize                   int64
+	concurrency                int
 }
 
 // NewConfigMapChecker is a factory method that returns a new PeriodicConfigMapChecker
-func NewConfigMapChecker(period time.Duration, labelSelectors, includeConfigMapsDataGlobs, excludeConfigMapsDataGlobs, annotationSelectors, namespaces, nsLabelSelector []string, kubeconfigPath string, e *exporters.ConfigMapExporter) *PeriodicConfigMapChecker {
+func NewConfigMapChecker(period time.Duration, labelSelectors, includeConfigMapsDataGlobs, excludeConfigMapsDataGlobs, annotationSelectors, labelsToMetric, namespaces, nsLabelSelector, excludeNamespaces []string, client kubernetes.Interface, e *exporters.ConfigMapExporter, pageSize int64, concurrency int, thresholds exporters.ExpiryThresholds) *PeriodicConfigMapChecker {
 	return &PeriodicConfigMapChecker{
 		period:                     period,
 		labelSelectors:             labelSelectors,
 		annotationSelectors:        annotationSelectors,
+		labelsToMetric:             labelsToMetric,
 		namespaces:                 namespaces,
-		kubeconfigPath:             kubeconfigPath,
+		client:                     client,
 		exporter:                   e,
+		thresholds:                 thresholds,
 		includeConfigMapsDataGlobs: includeConfigMapsDataGlobs,
 		excludeConfigMapsDataGlobs: excludeConfigMapsDataGlobs,
 		nsLabelSelector:            nsLabelSelector,
+		excludeNamespaces:          excludeNamespaces,
+		pageSize:                   pageSize,
+		concurrency:                concurrency,
 	}
 }
 
-// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.
-func (p *PeriodicConfigMapChecker) StartChecking() {
-	config, err := clientcmd.BuildConfigFromFlags("", p.kubeconfigPath)
-	if err != nil {
-		slog.Error("Error building kubeconfig", "error", err)
-	}
+// namespaceSelector returns the selector for the namespaces the checker scans
+func (p *PeriodicConfigMapChecker) namespaceSelector() namespaceSelector {
+	return namespaceSelector{namespaces: p.namespaces, exclude: p.excludeNamespaces, labelSelectors: p.nsLabelSelector}
+}
 
-	// creates the clientset
-	client, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		slog.Error("kubernetes.NewForConfig failed", "error", err)
+// Permissions returns the requests the checker makes against the API server.  ConfigMaps in discovered namespaces can
+// only be checked once they are found.
+func (p *PeriodicConfigMapChecker) Permissions() []kubeclient.Permission {
+	if p.namespaceSelector().discovers() {
+		return []kubeclient.Permission{
+			{Verb: "list", Resource: "namespaces"},
+			{Verb: "list", Resource: "configmaps", Namespace: metav1.NamespaceAll},
+		}
 	}
+	return namespacedPermissions("list", "", "configmaps", p.namespaces)
+}
 
+// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicConfigMapChecker) StartChecking(ctx context.Context) {
 	periodChannel := time.Tick(p.period)
 
 	if strings.Join(p.namespaces, ", ") != "" {
@@ -65,120 +80,107 @@ func (p *PeriodicConfigMapChecker) StartChecking() {
 	for {
 		slog.Info("Begin periodic check")
 
-		p.exporter.ResetMetrics()
-
-		var configMaps []corev1.ConfigMap
-		var namespacesToCheck []string
+		p.exporter.BeginScan()
+		scanCtx, cancel := newScanContext(ctx, p.period)
 
-		if len(p.nsLabelSelector) > 0 { // re-discover namespaces each tick to notice new NSs
-			for _, nsLabelSelector := range p.nsLabelSelector {
-				var nss *corev1.NamespaceList
-				nss, err = client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
-					LabelSelector: nsLabelSelector,
-				})
-				if err != nil {
-					slog.Error("Error requesting namespaces", "error", err)
-					metrics.ErrorTotal.Inc()
-				}
+		// re-discover namespaces each tick to notice new NSs
+		namespacesToCheck, err := p.namespaceSelector().resolve(scanCtx, p.client, p.pageSize)
+		if err != nil {
+			slog.Error("Error requesting namespaces", "error", err)
+			p.exporter.ScanError(exporters.ReasonAPIError)
+		}
 
-				for _, ns := range nss.Items {
-					namespacesToCheck = append(namespacesToCheck, ns.GetObjectMeta().GetName())
-					slog.Info("Adding namespace to check", "namespace", ns.GetObjectMeta().GetName())
+		configMaps, wait := workers(p.concurrency, func(configMap *corev1.ConfigMap) {
+			p.exporter.ObjectScanned()
+			p.exportConfigMap(configMap)
+		})
+		forEach(namespacedLists(namespacesToCheck, labelSelectorOptions(p.labelSelectors)), p.concurrency, func(l namespacedList) {
+			err := listPages(scanCtx, l.options, p.pageSize, p.client.CoreV1().ConfigMaps(l.namespace).List, func(c *corev1.ConfigMapList) {
+				for i := range c.Items {
+					configMaps <- &c.Items[i]
 				}
+			})
+			if err != nil {
+				slog.Error("Error requesting configMaps", "namespace", l.namespace, "error", err)
+				p.exporter.ScanError(exporters.ReasonAPIError)
 			}
-		} else {
-			namespacesToCheck = p.namespaces
+		})
+		wait()
+
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
 		}
 
-		for _, ns := range namespacesToCheck {
-			if len(p.labelSelectors) > 0 {
-				for _, labelSelector := range p.labelSelectors {
-					var c *corev1.ConfigMapList
-					c, err = client.CoreV1().ConfigMaps(ns).List(context.TODO(), metav1.ListOptions{
-						LabelSelector: labelSelector,
-					})
-					if err != nil {
-						slog.Error("Error requesting configMaps", "error", err)
-						metrics.ErrorTotal.Inc()
-						continue
-					}
-					configMaps = append(configMaps, c.Items...)
-				}
-			} else {
-				var c *corev1.ConfigMapList
-				c, err = client.CoreV1().ConfigMaps(ns).List(context.TODO(), metav1.ListOptions{})
-				if err != nil {
-					slog.Error("Error requesting configMaps", "error", err)
-					metrics.ErrorTotal.Inc()
-					continue
-				}
-				configMaps = append(configMaps, c.Items...)
-			}
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
 		}
+	}
+}
 
-		for _, configMap := range configMaps {
-			include, exclude := false, false
-			slog.Info("Reviewing configMap", "name", configMap.GetName(), "namespace", configMap.GetNamespace())
-
-			if len(p.annotationSelectors) > 0 {
-				matches := false
-				annotations := configMap.GetAnnotations()
-				for _, selector := range p.annotationSelectors {
-					_, ok := annotations[selector]
-					if ok {
-						matches = true
-						break
-					}
-				}
+// exportConfigMap publishes the certs of a configMap that matches the annotation selectors and globs
+func (p *PeriodicConfigMapChecker) exportConfigMap(configMap *corev1.ConfigMap) {
+	var err error
+	include, exclude := false, false
+	slog.Info("Reviewing configMap", "name", configMap.GetName(), "namespace", configMap.GetNamespace())
 
-				if !matches {
-					continue
-				}
+	if !matchAnnotations(p.annotationSelectors, configMap.GetAnnotations()) {
+		return
+	}
+	slog.Info("Annotations matched. Parsing configMap.")
+	thresholds, err := expiryThresholds(p.thresholds, configMap.GetAnnotations())
+	if err != nil {
+		slog.Error("Error reading expiry thresholds", "configmap", configMap.Name, "namespace", configMap.Namespace, "error", err)
+		p.exporter.ScanError(exporters.ReasonParseError)
+	}
+	published := false
+
+	for name, data := range configMap.Data {
+		include, exclude = false, false
+
+		for _, glob := range p.includeConfigMapsDataGlobs {
+			include, err = filepath.Match(glob, name)
+			if err != nil {
+				slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
+				p.exporter.ScanError(exporters.ReasonGlobError)
+				continue
 			}
-			slog.Info("Annotations matched. Parsing configMap.")
-
-			for name, data := range configMap.Data {
-				include, exclude = false, false
-
-				for _, glob := range p.includeConfigMapsDataGlobs {
-					include, err = filepath.Match(glob, name)
-					if err != nil {
-						slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
-						metrics.ErrorTotal.Inc()
-						continue
-					}
-
-					if include {
-						break
-					}
-				}
 
-				for _, glob := range p.excludeConfigMapsDataGlobs {
-					exclude, err = filepath.Match(glob, name)
-					if err != nil {
-						slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
-						metrics.ErrorTotal.Inc()
-						continue
-					}
-
-					if exclude {
-						break
-					}
-				}
+			if include {
+				break
+			}
+		}
 
-				if include && !exclude {
-					slog.Info("Publishing metrics", "secret", configMap.Name, "namespace", configMap.Namespace, "key", name)
-					err = p.exporter.ExportMetrics([]byte(data), name, configMap.Name, configMap.Namespace)
-					if err != nil {
-						slog.Error("Error exporting configMap", "error", err)
-						metrics.ErrorTotal.Inc()
-					}
-				} else {
-					slog.Info("Ignoring key - does not match filters", "key", name, "include_globs", p.includeConfigMapsDataGlobs, "exclude_globs", p.excludeConfigMapsDataGlobs)
-				}
+		for _, glob := range p.excludeConfigMapsDataGlobs {
+			exclude, err = filepath.Match(glob, name)
+			if err != nil {
+				slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
+				p.exporter.ScanError(exporters.ReasonGlobError)
+				continue
+			}
+
+			if exclude {
+				break
 			}
 		}
 
-		<-periodChannel
+		if include && !exclude {
+			slog.Info("Publishing metrics", "secret", configMap.Name, "namespace", configMap.Namespace, "key", name)
+			published = true
+			err = p.exporter.ExportMetrics([]byte(data), name, configMap.Name, configMap.Namespace, thresholds)
+			if err != nil {
+				slog.Error("Error exporting configMap", "error", err)
+				p.exporter.ScanError(exporters.ReasonParseError)
+			}
+		} else {
+			slog.Info("Ignoring key - does not match filters", "key", name, "include_globs", p.includeConfigMapsDataGlobs, "exclude_globs", p.excludeConfigMapsDataGlobs)
+		}
+	}
+	if published && len(p.labelsToMetric) > 0 {
+		names, values := objectMetricLabels(p.labelsToMetric, configMap)
+		p.exporter.ExportLabels(names, values, configMap.Name, configMap.Namespace)
 	}
 }
@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"k8s.io/client-go/kubernetes/fake"
 	"testing"
 	"time"
 
@@ -15,7 +16,7 @@ func TestNewConfigMapChecker(t *testing.T) {
 	annotationSelectors := []string{"annotation"}
 	namespaces := []string{"default"}
 	nsLabelSelector := []string{"env=prod"}
-	kubeconfigPath := "/path/to/kubeconfig"
+	client := fake.NewSimpleClientset()
 	exporter := &exporters.ConfigMapExporter{}
 
 	checker := NewConfigMapChecker(
@@ -24,10 +25,15 @@ func TestNewConfigMapChecker(t *testing.T) {
 		includeGlobs,
 		excludeGlobs,
 		annotationSelectors,
+		nil,
 		namespaces,
 		nsLabelSelector,
-		kubeconfigPath,
+		nil,
+		client,
 		exporter,
+		500,
+		4,
+		exporters.ExpiryThresholds{},
 	)
 
 	if checker == nil {
@@ -62,8 +68,8 @@ func TestNewConfigMapChecker(t *testing.T) {
 		t.Errorf("Expected %d nsLabelSelector, got %d", len(nsLabelSelector), len(checker.nsLabelSelector))
 	}
 
-	if checker.kubeconfigPath != kubeconfigPath {
-		t.Errorf("Expected kubeconfigPath '%s', got '%s'", kubeconfigPath, checker.kubeconfigPath)
+	if checker.client != client {
+		t.Error("Expected client to match provided client")
 	}
 
 	if checker.exporter != exporter {
@@ -78,10 +84,15 @@ func TestNewConfigMapChecker_EmptyParameters(t *testing.T) {
 		[]string{},
 		[]string{},
 		[]string{},
+		nil,
 		[]string{},
 		[]string{},
-		"",
 		nil,
+		nil,
+		nil,
+		0,
+		0,
+		exporters.ExpiryThresholds{},
 	)
 
 	if checker == nil {
@@ -115,10 +126,15 @@ func TestNewConfigMapChecker_MultipleValues(t *testing.T) {
 		includeGlobs,
 		excludeGlobs,
 		annotationSelectors,
+		nil,
 		namespaces,
 		nsLabelSelector,
-		"/etc/kubernetes/admin.conf",
+		nil,
+		nil,
 		&exporters.ConfigMapExporter{},
+		500,
+		4,
+		exporters.ExpiryThresholds{},
 	)
 
 	if len(checker.labelSelectors) != len(labelSelectors) {
@@ -0,0 +1,121 @@
+package checkers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+)
+
+// EndpointTarget is a TLS endpoint to probe
+type EndpointTarget struct {
+	Address    string
+	ServerName string
+	StartTLS   string
+	Timeout    time.Duration
+}
+
+// ParseEndpointTarget parses a target in the form host:port[,sni=name][,starttls=smtp|imap|postgres][,timeout=duration].
+// defaultTimeout is used if the target does not set its own timeout.
+func ParseEndpointTarget(s string, defaultTimeout time.Duration) (EndpointTarget, error) {
+	parts := strings.Split(s, ",")
+	target := EndpointTarget{
+		Address: strings.TrimSpace(parts[0]),
+		Timeout: defaultTimeout,
+	}
+
+	if _, _, err := net.SplitHostPort(target.Address); err != nil {
+		return EndpointTarget{}, fmt.Errorf("invalid endpoint %q: %w", s, err)
+	}
+
+	for _, option := range parts[1:] {
+		key, value, found := strings.Cut(strings.TrimSpace(option), "=")
+		if !found {
+			return EndpointTarget{}, fmt.Errorf("invalid option %q for endpoint %q", option, target.Address)
+		}
+
+		switch key {
+		case "sni":
+			target.ServerName = value
+		case "starttls":
+			if !slices.Contains([]string{startTLSSMTP, startTLSIMAP, startTLSPostgres}, value) {
+				return EndpointTarget{}, fmt.Errorf("unsupported starttls protocol %q for endpoint %q", value, target.Address)
+			}
+			target.StartTLS = value
+		case "timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return EndpointTarget{}, fmt.Errorf("invalid timeout %q for endpoint %q: %w", value, target.Address, err)
+			}
+			target.Timeout = timeout
+		default:
+			return EndpointTarget{}, fmt.Errorf("unknown option %q for endpoint %q", key, target.Address)
+		}
+	}
+
+	return target, nil
+}
+
+// PeriodicEndpointChecker is an object designed to probe TLS endpoints at a regular interval
+type PeriodicEndpointChecker struct {
+	period   time.Duration
+	targets  []EndpointTarget
+	exporter *exporters.EndpointExporter
+}
+
+// NewEndpointChecker is a factory method that returns a new PeriodicEndpointChecker
+func NewEndpointChecker(period time.Duration, targets []EndpointTarget, e *exporters.EndpointExporter) *PeriodicEndpointChecker {
+	return &PeriodicEndpointChecker{
+		period:   period,
+		targets:  targets,
+		exporter: e,
+	}
+}
+
+// StartChecking starts the periodic endpoint check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicEndpointChecker) StartChecking(ctx context.Context) {
+	periodChannel := time.Tick(p.period)
+
+	for {
+		slog.Info("Begin periodic check")
+
+		p.exporter.BeginScan()
+		scanCtx, cancel := newScanContext(ctx, p.period)
+		p.checkEndpoints(scanCtx)
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
+	}
+}
+
+// checkEndpoints probes every target once
+func (p *PeriodicEndpointChecker) checkEndpoints(ctx context.Context) {
+	for _, target := range p.targets {
+		p.exporter.ObjectScanned()
+		slog.Info("Probing endpoint", "endpoint", target.Address, "server_name", target.ServerName, "starttls", target.StartTLS)
+
+		certs, duration, err := probeTLS(ctx, target.Address, target.ServerName, target.StartTLS, target.Timeout)
+		if err != nil {
+			slog.Error("Error probing endpoint", "endpoint", target.Address, "error", err)
+			p.exporter.ScanError(exporters.ReasonProbeError)
+			p.exporter.ExportProbe(target.Address, target.ServerName, false, 0)
+			continue
+		}
+
+		p.exporter.ExportProbe(target.Address, target.ServerName, true, duration)
+		p.exporter.ExportMetrics(certs, target.Address, target.ServerName)
+	}
+}
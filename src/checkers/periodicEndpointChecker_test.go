@@ -0,0 +1,232 @@
+package checkers
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestParseEndpointTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    EndpointTarget
+		wantErr bool
+	}{
+		{
+			name:  "address only",
+			input: "example.com:443",
+			want:  EndpointTarget{Address: "example.com:443", Timeout: 5 * time.Second},
+		},
+		{
+			name:  "all options",
+			input: "mail.example.com:25,sni=smtp.example.com,starttls=smtp,timeout=3s",
+			want:  EndpointTarget{Address: "mail.example.com:25", ServerName: "smtp.example.com", StartTLS: "smtp", Timeout: 3 * time.Second},
+		},
+		{
+			name:    "missing port",
+			input:   "example.com",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported starttls protocol",
+			input:   "example.com:21,starttls=ftp",
+			wantErr: true,
+		},
+		{
+			name:    "invalid timeout",
+			input:   "example.com:443,timeout=soon",
+			wantErr: true,
+		},
+		{
+			name:    "unknown option",
+			input:   "example.com:443,verify=true",
+			wantErr: true,
+		},
+		{
+			name:    "option without value",
+			input:   "example.com:443,sni",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEndpointTarget(tt.input, 5*time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEndpointTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEndpointTarget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriodicEndpointChecker_CheckEndpoints(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// A listener that is closed straight away gives an address that refuses connections.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closedAddress := closed.Addr().String()
+	closed.Close()
+
+	exporter := &exporters.EndpointExporter{}
+	exporter.ResetMetrics()
+	checker := NewEndpointChecker(time.Hour, []EndpointTarget{
+		{Address: server.Listener.Addr().String(), ServerName: "example.com", Timeout: 5 * time.Second},
+		{Address: closedAddress, Timeout: time.Second},
+	}, exporter)
+
+	exporter.BeginScan()
+	checker.checkEndpoints(context.Background())
+	exporter.EndScan()
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	success := map[string]float64{}
+	foundExpiry := false
+	foundDuration := false
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch mf.GetName() {
+			case "cert_exporter_endpoint_probe_success":
+				success[labels["endpoint"]] = metric.GetGauge().GetValue()
+			case "cert_exporter_endpoint_expires_in_seconds":
+				if labels["endpoint"] == server.Listener.Addr().String() && labels["server_name"] == "example.com" {
+					foundExpiry = metric.GetGauge().GetValue() > 0
+				}
+			case "cert_exporter_endpoint_handshake_duration_seconds":
+				if labels["endpoint"] == closedAddress {
+					t.Error("Expected no handshake duration for a failed probe")
+				}
+				foundDuration = true
+			}
+		}
+	}
+
+	if success[server.Listener.Addr().String()] != 1 {
+		t.Error("Expected probe of the TLS server to succeed")
+	}
+	if v, ok := success[closedAddress]; !ok || v != 0 {
+		t.Error("Expected failed probe to be exported as 0")
+	}
+	if !foundExpiry {
+		t.Error("Expected expiry metric for the served certificate")
+	}
+	if !foundDuration {
+		t.Error("Expected handshake duration for the successful probe")
+	}
+}
+
+func TestProbeTLS_StartTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		protocol string
+		dialogue func(conn net.Conn, reader *bufio.Reader) error
+	}{
+		{
+			protocol: startTLSSMTP,
+			dialogue: func(conn net.Conn, reader *bufio.Reader) error {
+				io.WriteString(conn, "220-mail.example.com ESMTP\r\n220 ready\r\n")
+				if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "EHLO") {
+					return err
+				}
+				io.WriteString(conn, "250-mail.example.com\r\n250 STARTTLS\r\n")
+				if line, err := reader.ReadString('\n'); err != nil || line != "STARTTLS\r\n" {
+					return err
+				}
+				_, err := io.WriteString(conn, "220 go ahead\r\n")
+				return err
+			},
+		},
+		{
+			protocol: startTLSIMAP,
+			dialogue: func(conn net.Conn, reader *bufio.Reader) error {
+				io.WriteString(conn, "* OK IMAP4rev1 ready\r\n")
+				if line, err := reader.ReadString('\n'); err != nil || line != "a001 STARTTLS\r\n" {
+					return err
+				}
+				_, err := io.WriteString(conn, "a001 OK Begin TLS negotiation now\r\n")
+				return err
+			},
+		},
+		{
+			protocol: startTLSPostgres,
+			dialogue: func(conn net.Conn, reader *bufio.Reader) error {
+				request := make([]byte, 8)
+				if _, err := io.ReadFull(reader, request); err != nil {
+					return err
+				}
+				if binary.BigEndian.Uint32(request[4:8]) != postgresSSLRequestCode {
+					return io.ErrUnexpectedEOF
+				}
+				_, err := conn.Write([]byte{'S'})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+			defer listener.Close()
+
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				if err := tt.dialogue(conn, bufio.NewReader(conn)); err != nil {
+					return
+				}
+				tlsConn := tls.Server(conn, server.TLS)
+				tlsConn.Handshake()
+			}()
+
+			certs, duration, err := probeTLS(context.Background(), listener.Addr().String(), "example.com", tt.protocol, 5*time.Second)
+			if err != nil {
+				t.Fatalf("probeTLS() error = %v", err)
+			}
+			if len(certs) == 0 {
+				t.Error("Expected the served certificate chain")
+			}
+			if duration <= 0 {
+				t.Error("Expected a positive handshake duration")
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package checkers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
+)
+
+// IngressControllerService identifies the Service of the ingress controller that serves ingress TLS hosts
+type IngressControllerService struct {
+	Namespace string
+	Name      string
+	Port      string
+}
+
+// ParseIngressControllerService parses a service reference in the form namespace/name[:port].  The port may be a
+// number or the name of a service port and defaults to 443.
+func ParseIngressControllerService(s string) (IngressControllerService, error) {
+	ref, port, found := strings.Cut(s, ":")
+	if !found {
+		port = "443"
+	}
+
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found || namespace == "" || name == "" || port == "" {
+		return IngressControllerService{}, fmt.Errorf("invalid ingress controller service %q, expected namespace/name[:port]", s)
+	}
+
+	return IngressControllerService{Namespace: namespace, Name: name, Port: port}, nil
+}
+
+// PeriodicIngressChecker is an object designed to check the TLS configuration of ingresses at a regular interval
+type PeriodicIngressChecker struct {
+	period              time.Duration
+	labelSelectors      []string
+	client              kubernetes.Interface
+	annotationSelectors []string
+	namespaces          []string
+	controllerService   *IngressControllerService
+	probeTimeout        time.Duration
+	exporter            *exporters.IngressExporter
+}
+
+// NewIngressChecker is a factory method that returns a new PeriodicIngressChecker.  If controllerService is not nil the
+// certs served for every ingress host are probed through that service as well.
+func NewIngressChecker(period time.Duration, labelSelectors, annotationSelectors, namespaces []string, controllerService *IngressControllerService, probeTimeout time.Duration, client kubernetes.Interface, e *exporters.IngressExporter) *PeriodicIngressChecker {
+	return &PeriodicIngressChecker{
+		period:              period,
+		labelSelectors:      labelSelectors,
+		annotationSelectors: annotationSelectors,
+		namespaces:          namespaces,
+		controllerService:   controllerService,
+		probeTimeout:        probeTimeout,
+		client:              client,
+		exporter:            e,
+	}
+}
+
+// Permissions returns the requests the checker makes against the API server
+func (p *PeriodicIngressChecker) Permissions() []kubeclient.Permission {
+	permissions := namespacedPermissions("list", "networking.k8s.io", "ingresses", p.namespaces)
+	permissions = append(permissions, namespacedPermissions("get", "", "secrets", p.namespaces)...)
+	if p.controllerService != nil {
+		permissions = append(permissions, kubeclient.Permission{Verb: "get", Resource: "services", Namespace: p.controllerService.Namespace})
+	}
+	return permissions
+}
+
+// StartChecking starts the periodic ingress check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicIngressChecker) StartChecking(ctx context.Context) {
+	periodChannel := time.Tick(p.period)
+	if strings.Join(p.namespaces, ", ") != "" {
+		slog.Info("Scan ingresses", "target", strings.Join(p.namespaces, ", "))
+	}
+	for {
+		slog.Info("Begin periodic check")
+
+		p.exporter.BeginScan()
+		scanCtx, cancel := newScanContext(ctx, p.period)
+		p.checkIngresses(scanCtx, p.client)
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
+	}
+}
+
+// checkIngresses exports the TLS state of every selected ingress once
+func (p *PeriodicIngressChecker) checkIngresses(ctx context.Context, client kubernetes.Interface) {
+	var ingresses []networkingv1.Ingress
+
+	for _, ns := range p.namespaces {
+		if len(p.labelSelectors) > 0 {
+			for _, labelSelector := range p.labelSelectors {
+				i, err := client.NetworkingV1().Ingresses(ns).List(ctx, metav1.ListOptions{
+					LabelSelector: labelSelector,
+				})
+				if err != nil {
+					slog.Error("Error requesting ingresses", "error", err)
+					p.exporter.ScanError(exporters.ReasonAPIError)
+					continue
+				}
+				ingresses = append(ingresses, i.Items...)
+			}
+		} else {
+			i, err := client.NetworkingV1().Ingresses(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				slog.Error("Error requesting ingresses", "error", err)
+				p.exporter.ScanError(exporters.ReasonAPIError)
+				continue
+			}
+			ingresses = append(ingresses, i.Items...)
+		}
+	}
+
+	controllerAddress := ""
+	if p.controllerService != nil && len(ingresses) > 0 {
+		var err error
+		controllerAddress, err = p.resolveControllerAddress(ctx, client)
+		if err != nil {
+			slog.Error("Error resolving ingress controller service", "service", p.controllerService.Namespace+"/"+p.controllerService.Name, "error", err)
+			p.exporter.ScanError(exporters.ReasonAPIError)
+		}
+	}
+
+	for _, ingress := range ingresses {
+		p.exporter.ObjectScanned()
+		slog.Info("Reviewing ingress", "name", ingress.GetName(), "namespace", ingress.GetNamespace())
+
+		if !matchAnnotations(p.annotationSelectors, ingress.GetAnnotations()) {
+			continue
+		}
+		slog.Info("Annotations matched. Parsing ingress.")
+
+		for _, tls := range ingress.Spec.TLS {
+			p.checkIngressTLS(ctx, client, &ingress, tls, controllerAddress)
+		}
+	}
+}
+
+// checkIngressTLS exports the certs of the secret referenced by a TLS entry and, if an ingress controller address is
+// known, the certs served for each of its hosts
+func (p *PeriodicIngressChecker) checkIngressTLS(ctx context.Context, client kubernetes.Interface, ingress *networkingv1.Ingress, tls networkingv1.IngressTLS, controllerAddress string) {
+	hosts := tls.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+
+	var secret *corev1.Secret
+	if tls.SecretName != "" {
+		var err error
+		secret, err = client.CoreV1().Secrets(ingress.Namespace).Get(ctx, tls.SecretName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			slog.Warn("Ingress references missing TLS secret", "ingress", ingress.Name, "namespace", ingress.Namespace, "secret", tls.SecretName)
+			p.exporter.ExportSecretMissing(ingress.Name, ingress.Namespace, tls.SecretName, true)
+		case err != nil:
+			slog.Error("Error requesting secret", "secret", tls.SecretName, "namespace", ingress.Namespace, "error", err)
+			p.exporter.ScanError(exporters.ReasonObjectError)
+		default:
+			p.exporter.ExportSecretMissing(ingress.Name, ingress.Namespace, tls.SecretName, false)
+		}
+	}
+
+	for _, host := range hosts {
+		secretFingerprint := ""
+		if secret != nil {
+			var err error
+			slog.Info("Publishing metrics", "ingress", ingress.Name, "namespace", ingress.Namespace, "host", host, "secret", tls.SecretName)
+			secretFingerprint, err = p.exporter.ExportSecretMetrics(secret.Data[corev1.TLSCertKey], ingress.Name, ingress.Namespace, host, tls.SecretName)
+			if err != nil {
+				slog.Error("Error exporting ingress secret", "secret", tls.SecretName, "namespace", ingress.Namespace, "error", err)
+				p.exporter.ScanError(exporters.ReasonParseError)
+			}
+		}
+
+		if controllerAddress == "" {
+			continue
+		}
+
+		certs, _, err := probeTLS(ctx, controllerAddress, host, "", p.probeTimeout)
+		if err != nil {
+			slog.Error("Error probing ingress host", "ingress", ingress.Name, "namespace", ingress.Namespace, "host", host, "error", err)
+			p.exporter.ScanError(exporters.ReasonProbeError)
+			p.exporter.ExportProbe(ingress.Name, ingress.Namespace, host, false)
+			continue
+		}
+		p.exporter.ExportProbe(ingress.Name, ingress.Namespace, host, true)
+
+		servedFingerprint := p.exporter.ExportServedMetrics(certs, ingress.Name, ingress.Namespace, host, tls.SecretName)
+		if secretFingerprint != "" && servedFingerprint != "" {
+			if secretFingerprint != servedFingerprint {
+				slog.Warn("Ingress serves a different cert than its TLS secret", "ingress", ingress.Name, "namespace", ingress.Namespace, "host", host, "secret", tls.SecretName)
+			}
+			p.exporter.ExportFingerprintMismatch(ingress.Name, ingress.Namespace, host, tls.SecretName, secretFingerprint != servedFingerprint)
+		}
+	}
+}
+
+// resolveControllerAddress looks up the cluster IP and port of the ingress controller service
+func (p *PeriodicIngressChecker) resolveControllerAddress(ctx context.Context, client kubernetes.Interface) (string, error) {
+	svc, err := client.CoreV1().Services(p.controllerService.Namespace).Get(ctx, p.controllerService.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		return "", fmt.Errorf("service has no cluster IP")
+	}
+
+	port := p.controllerService.Port
+	if _, err := strconv.Atoi(port); err != nil {
+		found := false
+		for _, servicePort := range svc.Spec.Ports {
+			if servicePort.Name == port {
+				port = strconv.Itoa(int(servicePort.Port))
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("service has no port named %q", port)
+		}
+	}
+
+	return net.JoinHostPort(svc.Spec.ClusterIP, port), nil
+}
@@ -0,0 +1,160 @@
+package checkers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestParseIngressControllerService(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    IngressControllerService
+		wantErr bool
+	}{
+		{
+			name:  "default port",
+			input: "ingress-nginx/ingress-nginx-controller",
+			want:  IngressControllerService{Namespace: "ingress-nginx", Name: "ingress-nginx-controller", Port: "443"},
+		},
+		{
+			name:  "named port",
+			input: "ingress-nginx/ingress-nginx-controller:https",
+			want:  IngressControllerService{Namespace: "ingress-nginx", Name: "ingress-nginx-controller", Port: "https"},
+		},
+		{
+			name:    "missing namespace",
+			input:   "ingress-nginx-controller",
+			wantErr: true,
+		},
+		{
+			name:    "empty port",
+			input:   "ingress-nginx/ingress-nginx-controller:",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIngressControllerService(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIngressControllerService() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIngressControllerService() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriodicIngressChecker_CheckIngresses(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, serverPort, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to split server address: %v", err)
+	}
+	port, err := strconv.Atoi(serverPort)
+	if err != nil {
+		t.Fatalf("Failed to parse server port: %v", err)
+	}
+
+	// The secret holds a different cert than the one the test server presents.
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "app.example.com", Days: 30})
+
+	client := fake.NewSimpleClientset(
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{"app.example.com"}, SecretName: "app-tls"},
+					{Hosts: []string{"old.example.com"}, SecretName: "old-tls"},
+				},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-tls", Namespace: "default"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{corev1.TLSCertKey: cert.CertPEM},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "controller", Namespace: "ingress"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "127.0.0.1",
+				Ports:     []corev1.ServicePort{{Name: "https", Port: int32(port)}},
+			},
+		},
+	)
+
+	exporter := &exporters.IngressExporter{}
+	exporter.ResetMetrics()
+	checker := NewIngressChecker(time.Hour, nil, nil, []string{""}, &IngressControllerService{Namespace: "ingress", Name: "controller", Port: "https"}, 5*time.Second, nil, exporter)
+
+	exporter.BeginScan()
+	checker.checkIngresses(context.Background(), client)
+	exporter.EndScan()
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	missing := map[string]float64{}
+	mismatch := map[string]float64{}
+	probe := map[string]float64{}
+	sources := map[string]bool{}
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch mf.GetName() {
+			case "cert_exporter_ingress_tls_secret_missing":
+				missing[labels["secret_name"]] = metric.GetGauge().GetValue()
+			case "cert_exporter_ingress_tls_fingerprint_mismatch":
+				mismatch[labels["host"]] = metric.GetGauge().GetValue()
+			case "cert_exporter_ingress_probe_success":
+				probe[labels["host"]] = metric.GetGauge().GetValue()
+			case "cert_exporter_ingress_expires_in_seconds":
+				if labels["host"] == "app.example.com" {
+					sources[labels["source"]] = true
+				}
+			}
+		}
+	}
+
+	if missing["app-tls"] != 0 || missing["old-tls"] != 1 {
+		t.Errorf("Expected only old-tls to be reported missing, got %v", missing)
+	}
+	if !sources[exporters.IngressSourceSecret] || !sources[exporters.IngressSourceServed] {
+		t.Errorf("Expected expiry metrics from both the secret and the served cert, got %v", sources)
+	}
+	if probe["app.example.com"] != 1 || probe["old.example.com"] != 1 {
+		t.Errorf("Expected both hosts to be probed successfully, got %v", probe)
+	}
+	if v, ok := mismatch["app.example.com"]; !ok || v != 1 {
+		t.Errorf("Expected a fingerprint mismatch for app.example.com, got %v", mismatch)
+	}
+	if _, ok := mismatch["old.example.com"]; ok {
+		t.Error("Expected no fingerprint comparison without a secret")
+	}
+}
@@ -2,63 +2,134 @@ package checkers
 
 import (
 	"context"
+	"crypto/x509"
+	"fmt"
 	"log/slog"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/joe-elliott/cert-exporter/src/exporters"
-	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
 )
 
+// watchReferenceTimeout bounds reading the objects a secret refers to from an informer event handler, which holds up
+// the other events of the informer meanwhile
+const watchReferenceTimeout = 10 * time.Second
+
 // PeriodicSecretChecker is an object designed to check for files on disk at a regular interval
 type PeriodicSecretChecker struct {
 	period                  time.Duration
 	labelSelectors          []string
-	kubeconfigPath          string
+	client                  kubernetes.Interface
+	informers               *kubeclient.Informers
 	annotationSelectors     []string
+	labelsToMetric          []string
 	namespaces              []string
 	exporter                *exporters.SecretExporter
+	thresholds              exporters.ExpiryThresholds
 	includeSecretsDataGlobs []string
 	excludeSecretsDataGlobs []string
 	includeSecretsTypes     []string
 	nsLabelSelector         []string
+	excludeNamespaces       []string
+	pageSize                int64
+	concurrency             int
+	passwordKey             string
+	passwordSources         exporters.PasswordSources
+	checkKeypair            bool
+	verifyChain             bool
+	chainRoots              ChainRoots
+
+	// root certs loaded by rootPool
+	rootsMu     sync.Mutex
+	roots       *x509.CertPool
+	rootsErr    error
+	rootsLoaded time.Time
+
+	// password secrets read by referencedSecret, by namespace/name
+	referencedMu sync.Mutex
+	referenced   map[string]cachedSecret
+
+	// state used by StartWatching
+	watchMu         sync.Mutex
+	secretStores    []cache.Store
+	namespaceStores []cache.Store
+	registrations   []informerRegistration
+	releases        []func()
 }
 
 // NewSecretChecker is a factory method that returns a new PeriodicSecretChecker
-func NewSecretChecker(period time.Duration, labelSelectors, includeSecretsDataGlobs, excludeSecretsDataGlobs, annotationSelectors, namespaces, nsLabelSelector []string, kubeconfigPath string, e *exporters.SecretExporter, includeSecretsTypes []string) *PeriodicSecretChecker {
+func NewSecretChecker(period time.Duration, labelSelectors, includeSecretsDataGlobs, excludeSecretsDataGlobs, annotationSelectors, labelsToMetric, namespaces, nsLabelSelector, excludeNamespaces []string, client kubernetes.Interface, informers *kubeclient.Informers, e *exporters.SecretExporter, includeSecretsTypes []string, pageSize int64, concurrency int, passwordKey string, passwordSources exporters.PasswordSources, checkKeypair, verifyChain bool, chainRoots ChainRoots, thresholds exporters.ExpiryThresholds) *PeriodicSecretChecker {
 	return &PeriodicSecretChecker{
 		period:                  period,
 		labelSelectors:          labelSelectors,
 		annotationSelectors:     annotationSelectors,
+		labelsToMetric:          labelsToMetric,
 		namespaces:              namespaces,
-		kubeconfigPath:          kubeconfigPath,
+		client:                  client,
+		informers:               informers,
 		exporter:                e,
+		thresholds:              thresholds,
 		includeSecretsDataGlobs: includeSecretsDataGlobs,
 		excludeSecretsDataGlobs: excludeSecretsDataGlobs,
 		includeSecretsTypes:     includeSecretsTypes,
 		nsLabelSelector:         nsLabelSelector,
+		excludeNamespaces:       excludeNamespaces,
+		pageSize:                pageSize,
+		concurrency:             concurrency,
+		passwordKey:             passwordKey,
+		passwordSources:         passwordSources,
+		checkKeypair:            checkKeypair,
+		verifyChain:             verifyChain,
+		chainRoots:              chainRoots,
 	}
 }
 
-// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.
-func (p *PeriodicSecretChecker) StartChecking() {
-	config, err := clientcmd.BuildConfigFromFlags("", p.kubeconfigPath)
-	if err != nil {
-		slog.Error("Error building kubeconfig", "error", err)
+// namespaceSelector returns the selector for the namespaces the checker scans
+func (p *PeriodicSecretChecker) namespaceSelector() namespaceSelector {
+	return namespaceSelector{namespaces: p.namespaces, exclude: p.excludeNamespaces, labelSelectors: p.nsLabelSelector}
+}
+
+// Permissions returns the requests the checker makes against the API server.  watch tells whether it runs through
+// StartWatching.  Secrets in discovered namespaces can only be checked once they are found.
+func (p *PeriodicSecretChecker) Permissions(watch bool) []kubeclient.Permission {
+	var permissions []kubeclient.Permission
+	if namespace, _, ok := splitConfigMapRef(p.chainRoots.ConfigMap); ok && p.verifyChain {
+		permissions = append(permissions, kubeclient.Permission{Verb: "list", Resource: "configmaps", Namespace: namespace})
+	}
+	if !watch {
+		if p.namespaceSelector().discovers() {
+			return append(permissions,
+				kubeclient.Permission{Verb: "list", Resource: "namespaces"},
+				kubeclient.Permission{Verb: "list", Resource: "secrets", Namespace: metav1.NamespaceAll})
+		}
+		return append(permissions, namespacedPermissions("list", "", "secrets", p.namespaces)...)
 	}
 
-	// creates the clientset
-	client, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		slog.Error("kubernetes.NewForConfig failed", "error", err)
+	namespaces := p.namespaces
+	if p.namespaceSelector().discovers() {
+		namespaces = []string{metav1.NamespaceAll}
 	}
+	if len(p.nsLabelSelector) > 0 {
+		permissions = append(permissions,
+			kubeclient.Permission{Verb: "list", Resource: "namespaces"},
+			kubeclient.Permission{Verb: "watch", Resource: "namespaces"})
+	}
+	permissions = append(permissions, namespacedPermissions("list", "", "secrets", namespaces)...)
+	return append(permissions, namespacedPermissions("watch", "", "secrets", namespaces)...)
+}
 
+// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicSecretChecker) StartChecking(ctx context.Context) {
 	periodChannel := time.Tick(p.period)
 	if strings.Join(p.namespaces, ", ") != "" {
 		slog.Info("Scan secrets", "target", strings.Join(p.namespaces, ", "))
@@ -66,142 +137,497 @@ func (p *PeriodicSecretChecker) StartChecking() {
 	for {
 		slog.Info("Begin periodic check")
 
-		p.exporter.ResetMetrics()
-
-		var secrets []corev1.Secret
-		var namespacesToCheck []string
+		p.exporter.BeginScan()
+		scanCtx, cancel := newScanContext(ctx, p.period)
 
-		if len(p.nsLabelSelector) > 0 { // re-discover namespaces each tick to notice new NSs
-			for _, nsLabelSelector := range p.nsLabelSelector {
-				var nss *corev1.NamespaceList
-				nss, err = client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
-					LabelSelector: nsLabelSelector,
-				})
-				if err != nil {
-					slog.Error("Error requesting namespaces", "error", err)
-					metrics.ErrorTotal.Inc()
-				}
+		// re-discover namespaces each tick to notice new NSs
+		namespacesToCheck, err := p.namespaceSelector().resolve(scanCtx, p.client, p.pageSize)
+		if err != nil {
+			slog.Error("Error requesting namespaces", "error", err)
+			p.exporter.ScanError(exporters.ReasonAPIError)
+		}
 
-				for _, ns := range nss.Items {
-					namespacesToCheck = append(namespacesToCheck, ns.GetObjectMeta().GetName())
-					slog.Info("Adding namespace to check", "namespace", ns.GetObjectMeta().GetName())
-				}
+		// List calls and parsing run on separate bounded pools, so a slow namespace does not hold up parsing the
+		// secrets already listed.  The scan buffer publishes the result at once, so the order does not matter.
+		secrets, wait := workers(p.concurrency, func(secret *corev1.Secret) {
+			p.exporter.ObjectScanned()
+			if p.secretSelected(secret) {
+				p.exportSecret(scanCtx, secret)
 			}
-		} else {
-			namespacesToCheck = p.namespaces
-		}
-
-		for _, ns := range namespacesToCheck {
-			if len(p.labelSelectors) > 0 {
-				for _, labelSelector := range p.labelSelectors {
-					var s *corev1.SecretList
-					s, err = client.CoreV1().Secrets(ns).List(context.TODO(), metav1.ListOptions{
-						LabelSelector: labelSelector,
-					})
-					if err != nil {
-						slog.Error("Error requesting secrets", "error", err)
-						metrics.ErrorTotal.Inc()
-						continue
-					}
-					secrets = append(secrets, s.Items...)
+		})
+		forEach(namespacedLists(namespacesToCheck, p.listOptions()), p.concurrency, func(l namespacedList) {
+			err := listPages(scanCtx, l.options, p.pageSize, p.client.CoreV1().Secrets(l.namespace).List, func(s *corev1.SecretList) {
+				for i := range s.Items {
+					secrets <- &s.Items[i]
 				}
-			} else {
-				var s *corev1.SecretList
-				s, err = client.CoreV1().Secrets(ns).List(context.TODO(), metav1.ListOptions{})
-				if err != nil {
-					slog.Error("Error requesting secrets", "error", err)
-					metrics.ErrorTotal.Inc()
-					continue
-				}
-				secrets = append(secrets, s.Items...)
+			})
+			if err != nil {
+				slog.Error("Error requesting secrets", "namespace", l.namespace, "error", err)
+				p.exporter.ScanError(exporters.ReasonAPIError)
 			}
+		})
+		wait()
+
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
 		}
 
-		for _, secret := range secrets {
-			include, exclude := false, false
-			// If you want only a certain type of cert
-			if len(p.includeSecretsTypes) > 0 {
-				exclude = false
-				for _, t := range p.includeSecretsTypes {
-					if string(secret.Type) == t {
-						include = true
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
+	}
+}
+
+// listOptions returns the options of the lists that together return the secrets matching any label selector and
+// any included type.  A field selector only takes a single type, so every type is listed on its own.
+func (p *PeriodicSecretChecker) listOptions() []metav1.ListOptions {
+	var options []metav1.ListOptions
+	for _, o := range labelSelectorOptions(p.labelSelectors) {
+		for _, fieldSelector := range p.fieldSelectors() {
+			o.FieldSelector = fieldSelector
+			options = append(options, o)
+		}
+	}
+	return options
+}
+
+// fieldSelectors returns a field selector per included secret type, or a single empty one if every type is included
+func (p *PeriodicSecretChecker) fieldSelectors() []string {
+	if len(p.includeSecretsTypes) == 0 {
+		return []string{""}
+	}
+
+	fieldSelectors := make([]string, len(p.includeSecretsTypes))
+	for i, secretType := range p.includeSecretsTypes {
+		fieldSelectors[i] = fields.OneTermEqualSelector("type", secretType).String()
+	}
+	return fieldSelectors
+}
+
+// StartWatching keeps secret metrics up to date using shared informers instead of listing every secret
+// each polling period.  Most likely you want to run this as an independent go routine.  It removes its
+// event handlers and the series it exported once ctx is cancelled.
+func (p *PeriodicSecretChecker) StartWatching(ctx context.Context) {
+	if strings.Join(p.namespaces, ", ") != "" {
+		slog.Info("Watch secrets", "target", strings.Join(p.namespaces, ", "))
+	}
+	p.exporter.BeginSync()
+	p.watch(ctx)
+	p.exporter.EndSync()
+	<-ctx.Done()
+	p.unwatch()
+}
+
+// watch adds handlers to the shared namespace and secret informers, starting them if needed, and blocks
+// until the handlers have seen every cached object.  The handlers resync every polling period so expiry
+// durations are refreshed without hitting the API.  The API calls the handlers make are cancelled with ctx.
+func (p *PeriodicSecretChecker) watch(ctx context.Context) {
+	namespaces := p.namespaces
+	if p.namespaceSelector().discovers() {
+		// Secrets are watched in every namespace and those in namespaces that are not selected are ignored
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	if len(p.nsLabelSelector) > 0 {
+		for _, nsLabelSelector := range p.nsLabelSelector {
+			factory, release := p.informers.Factory(metav1.NamespaceAll, nsLabelSelector, "")
+			p.releases = append(p.releases, release)
+			informer := factory.Core().V1().Namespaces().Informer()
+			registration, err := informer.AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					if ns, ok := obj.(*corev1.Namespace); ok {
+						p.namespaceSelected(ctx, ns.Name)
 					}
-					if include {
-						continue
+				},
+				DeleteFunc: func(obj interface{}) {
+					if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+						obj = tombstone.Obj
 					}
-				}
-				if !include {
-					slog.Info("Ignoring secret - not in include-types", "secret", secret.GetName(), "namespace", secret.GetNamespace(), "type", secret.Type, "include_types", p.includeSecretsTypes)
-					continue
-				}
+					if ns, ok := obj.(*corev1.Namespace); ok {
+						p.namespaceDeselected(ns.Name)
+					}
+				},
+			}, p.period)
+			if err != nil {
+				slog.Error("Error adding namespace event handler", "error", err)
+				p.exporter.ScanError(exporters.ReasonAPIError)
+				continue
 			}
+			p.registrations = append(p.registrations, informerRegistration{informer, registration})
+			p.watchMu.Lock()
+			p.namespaceStores = append(p.namespaceStores, informer.GetStore())
+			p.watchMu.Unlock()
+			p.informers.Start()
+			cache.WaitForCacheSync(ctx.Done(), registration.HasSynced)
+		}
+	}
 
-			slog.Info("Reviewing secret", "name", secret.GetName(), "namespace", secret.GetNamespace())
+	handler := newInformerHandler(func(secret *corev1.Secret) {
+		p.upsertSecret(ctx, secret)
+	}, func(secret *corev1.Secret) {
+		p.removeSecret(ctx, secret)
+	})
+	for _, ns := range namespaces {
+		for _, options := range p.listOptions() {
+			factory, release := p.informers.Factory(ns, options.LabelSelector, options.FieldSelector)
+			p.releases = append(p.releases, release)
+			informer := factory.Core().V1().Secrets().Informer()
+			registration, err := informer.AddEventHandlerWithResyncPeriod(handler, p.period)
+			if err != nil {
+				slog.Error("Error adding secret event handler", "error", err)
+				p.exporter.ScanError(exporters.ReasonAPIError)
+				continue
+			}
+			p.registrations = append(p.registrations, informerRegistration{informer, registration})
+			// Handlers of the informers added before read the stores to resolve references
+			p.watchMu.Lock()
+			p.secretStores = append(p.secretStores, informer.GetStore())
+			p.watchMu.Unlock()
+			p.informers.Start()
+			cache.WaitForCacheSync(ctx.Done(), registration.HasSynced)
+		}
+	}
+}
 
-			if len(p.annotationSelectors) > 0 {
-				matches := false
-				annotations := secret.GetAnnotations()
-				for _, selector := range p.annotationSelectors {
-					_, ok := annotations[selector]
-					if ok {
-						matches = true
-						break
-					}
-				}
+// stores returns the secret and namespace informer stores added so far
+func (p *PeriodicSecretChecker) stores() (secretStores, namespaceStores []cache.Store) {
+	p.watchMu.Lock()
+	defer p.watchMu.Unlock()
 
-				if !matches {
-					continue
-				}
+	return p.secretStores, p.namespaceStores
+}
+
+// unwatch removes the event handlers from the shared informers and the series of every secret they know, and then
+// releases the informer factories, stopping those no other checker uses.
+func (p *PeriodicSecretChecker) unwatch() {
+	for _, r := range p.registrations {
+		if err := r.informer.RemoveEventHandler(r.registration); err != nil {
+			slog.Error("Error removing event handler", "error", err)
+		}
+	}
+
+	p.watchMu.Lock()
+	for _, store := range p.secretStores {
+		for _, obj := range store.List() {
+			if secret, ok := obj.(*corev1.Secret); ok {
+				p.exporter.DeleteMetrics(secret.Name, secret.Namespace)
 			}
-			slog.Info("Annotations matched. Parsing Secret.")
+		}
+	}
+	p.watchMu.Unlock()
 
-			for name, bytes := range secret.Data {
-				include, exclude = false, false
+	for _, release := range p.releases {
+		release()
+	}
+}
 
-				for _, glob := range p.includeSecretsDataGlobs {
-					include, err = filepath.Match(glob, name)
-					if err != nil {
-						slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
-						metrics.ErrorTotal.Inc()
-						continue
-					}
+// upsertSecret replaces the series for a single secret after it was added, updated or resynced.  The objects the
+// secret refers to are read before taking watchMu, so a slow API call does not hold up the other events.
+func (p *PeriodicSecretChecker) upsertSecret(ctx context.Context, secret *corev1.Secret) {
+	_, namespaceStores := p.stores()
+	selected := p.namespaceWatched(secret.Namespace, namespaceStores) && p.secretSelected(secret)
+	var refs secretReferences
+	if selected {
+		ctx, cancel := context.WithTimeout(ctx, min(p.period, watchReferenceTimeout))
+		refs = p.resolveReferences(ctx, secret)
+		cancel()
+	}
 
-					if include {
-						break
-					}
-				}
+	p.watchMu.Lock()
+	defer p.watchMu.Unlock()
 
-				for _, glob := range p.excludeSecretsDataGlobs {
-					exclude, err = filepath.Match(glob, name)
-					if err != nil {
-						slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
-						metrics.ErrorTotal.Inc()
-						continue
-					}
+	p.exporter.ObjectScanned()
+	p.exporter.BeginSecret(secret.Name, secret.Namespace)
+	// The namespace may have been deselected while the references were read
+	if selected && p.namespaceWatched(secret.Namespace, p.namespaceStores) {
+		p.publishSecret(secret, refs)
+	}
+	p.exporter.EndSecret()
+}
 
-					if exclude {
-						break
-					}
-				}
+// namespaceWatched tells whether the secrets of a namespace are exported in watch mode, given the namespace stores
+// added so far
+func (p *PeriodicSecretChecker) namespaceWatched(namespace string, namespaceStores []cache.Store) bool {
+	if !p.namespaceSelector().selects(namespace) {
+		return false
+	}
+	if len(namespaceStores) > 0 {
+		if _, ok := storesContain(namespaceStores, namespace); !ok {
+			return false
+		}
+	}
+	return true
+}
 
-				if include && !exclude {
-					slog.Info("Publishing metrics", "secret", secret.Name, "namespace", secret.Namespace, "key", name)
-					certPassword := ""
-					if certPasswortBytes, found := secret.Data["password"]; found {
-						certPassword = string(certPasswortBytes)
-					}
+// removeSecret drops the series for a secret unless another informer still selects it.
+func (p *PeriodicSecretChecker) removeSecret(ctx context.Context, secret *corev1.Secret) {
+	key, err := cache.MetaNamespaceKeyFunc(secret)
+	if err == nil {
+		secretStores, _ := p.stores()
+		if obj, ok := storesContain(secretStores, key); ok {
+			p.upsertSecret(ctx, obj.(*corev1.Secret))
+			return
+		}
+	}
 
-					err = p.exporter.ExportMetrics(bytes, name, secret.Name, secret.Namespace, certPassword)
-					if err != nil {
-						slog.Error("Error exporting secret", "error", err)
-						metrics.ErrorTotal.Inc()
-					}
-				} else {
-					slog.Info("Ignoring key - does not match filters", "key", name, "include_globs", p.includeSecretsDataGlobs, "exclude_globs", p.excludeSecretsDataGlobs)
-				}
+	p.watchMu.Lock()
+	defer p.watchMu.Unlock()
+	slog.Info("Removing secret metrics", "secret", secret.Name, "namespace", secret.Namespace)
+	p.exporter.DeleteMetrics(secret.Name, secret.Namespace)
+}
+
+// namespaceSelected exports the already cached secrets of a namespace that started matching the
+// namespace label selector.
+func (p *PeriodicSecretChecker) namespaceSelected(ctx context.Context, namespace string) {
+	slog.Info("Adding namespace to watch", "namespace", namespace)
+	secretStores, _ := p.stores()
+	for _, store := range secretStores {
+		for _, obj := range store.List() {
+			if secret, ok := obj.(*corev1.Secret); ok && secret.Namespace == namespace {
+				p.upsertSecret(ctx, secret)
 			}
 		}
+	}
+}
+
+// namespaceDeselected drops the series of every secret in a namespace that no longer matches the
+// namespace label selector.
+func (p *PeriodicSecretChecker) namespaceDeselected(namespace string) {
+	_, namespaceStores := p.stores()
+	if _, ok := storesContain(namespaceStores, namespace); ok {
+		return
+	}
+
+	p.watchMu.Lock()
+	defer p.watchMu.Unlock()
+	slog.Info("Removing namespace from watch", "namespace", namespace)
+	p.exporter.DeleteNamespaceMetrics(namespace)
+}
+
+// secretSelected applies the type and annotation filters to a secret.
+func (p *PeriodicSecretChecker) secretSelected(secret *corev1.Secret) bool {
+	// If you want only a certain type of cert
+	if len(p.includeSecretsTypes) > 0 && !slices.Contains(p.includeSecretsTypes, string(secret.Type)) {
+		slog.Info("Ignoring secret - not in include-types", "secret", secret.GetName(), "namespace", secret.GetNamespace(), "type", secret.Type, "include_types", p.includeSecretsTypes)
+		return false
+	}
+
+	slog.Info("Reviewing secret", "name", secret.GetName(), "namespace", secret.GetNamespace())
+
+	if !matchAnnotations(p.annotationSelectors, secret.GetAnnotations()) {
+		return false
+	}
+	slog.Info("Annotations matched. Parsing Secret.")
+	return true
+}
+
+// secretReferences are the passwords and root certs of a secret, which may have to be read from other objects
+type secretReferences struct {
+	passwords *exporters.Passwords
+	roots     *x509.CertPool
+	rootsErr  error
+}
+
+// resolveReferences reads the passwords and root certs of a secret
+func (p *PeriodicSecretChecker) resolveReferences(ctx context.Context, secret *corev1.Secret) secretReferences {
+	refs := secretReferences{passwords: p.passwords(ctx, secret)}
+	if p.verifyChain && secret.Type == corev1.SecretTypeTLS {
+		refs.roots, refs.rootsErr = p.rootPool(ctx)
+	}
+	return refs
+}
+
+// exportSecret publishes metrics for every data key of the secret that passes the include and exclude globs.
+func (p *PeriodicSecretChecker) exportSecret(ctx context.Context, secret *corev1.Secret) {
+	p.publishSecret(secret, p.resolveReferences(ctx, secret))
+}
+
+// publishSecret publishes the metrics of a secret whose references were already resolved
+func (p *PeriodicSecretChecker) publishSecret(secret *corev1.Secret, refs secretReferences) {
+	thresholds, err := expiryThresholds(p.thresholds, secret.GetAnnotations())
+	if err != nil {
+		slog.Error("Error reading expiry thresholds", "secret", secret.Name, "namespace", secret.Namespace, "error", err)
+		p.exporter.ScanError(exporters.ReasonParseError)
+	}
+	published := false
+	for name, bytes := range secret.Data {
+		include, exclude := false, false
+		var err error
+
+		for _, glob := range p.includeSecretsDataGlobs {
+			include, err = filepath.Match(glob, name)
+			if err != nil {
+				slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
+				p.exporter.ScanError(exporters.ReasonGlobError)
+				continue
+			}
+
+			if include {
+				break
+			}
+		}
+
+		for _, glob := range p.excludeSecretsDataGlobs {
+			exclude, err = filepath.Match(glob, name)
+			if err != nil {
+				slog.Error("Error matching glob", "glob", glob, "name", name, "error", err)
+				p.exporter.ScanError(exporters.ReasonGlobError)
+				continue
+			}
+
+			if exclude {
+				break
+			}
+		}
+
+		if include && !exclude {
+			slog.Info("Publishing metrics", "secret", secret.Name, "namespace", secret.Namespace, "key", name)
+			published = true
+
+			err = p.exporter.ExportMetrics(bytes, name, secret.Name, secret.Namespace, *refs.passwords, thresholds)
+			if err != nil {
+				slog.Error("Error exporting secret", "error", err)
+				p.exporter.ScanError(exporters.ReasonParseError)
+			}
+		} else {
+			slog.Info("Ignoring key - does not match filters", "key", name, "include_globs", p.includeSecretsDataGlobs, "exclude_globs", p.excludeSecretsDataGlobs)
+		}
+	}
+	if p.checkKeypair && secret.Type == corev1.SecretTypeTLS {
+		published = true
+		err := p.exporter.ExportKeypair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], secret.Name, secret.Namespace)
+		if err != nil {
+			slog.Error("Error checking keypair", "secret", secret.Name, "namespace", secret.Namespace, "error", err)
+			p.exporter.ScanError(exporters.ReasonParseError)
+		}
+	}
+	if p.verifyChain && secret.Type == corev1.SecretTypeTLS {
+		published = true
+		p.exportChain(secret, refs)
+	}
+	if published && len(p.labelsToMetric) > 0 {
+		names, values := objectMetricLabels(p.labelsToMetric, secret)
+		p.exporter.ExportLabels(names, values, secret.Name, secret.Namespace)
+	}
+}
+
+// exportChain verifies the cert chain of a kubernetes.io/tls secret against the root certs
+func (p *PeriodicSecretChecker) exportChain(secret *corev1.Secret, refs secretReferences) {
+	if refs.rootsErr != nil {
+		slog.Error("Error loading chain roots", "file", p.chainRoots.File, "configmap", p.chainRoots.ConfigMap, "error", refs.rootsErr)
+		p.exporter.ScanError(exporters.ReasonParseError)
+		return
+	}
+	err := p.exporter.ExportChain(secret.Data[corev1.TLSCertKey], refs.roots, corev1.TLSCertKey, secret.Name, secret.Namespace)
+	if err != nil {
+		slog.Error("Error verifying chain", "secret", secret.Name, "namespace", secret.Namespace, "error", err)
+		p.exporter.ScanError(exporters.ReasonParseError)
+	}
+}
 
-		<-periodChannel
+// rootPool returns the root certs chains are verified against, loading them at most once per polling period
+func (p *PeriodicSecretChecker) rootPool(ctx context.Context) (*x509.CertPool, error) {
+	p.rootsMu.Lock()
+	defer p.rootsMu.Unlock()
+
+	if p.rootsLoaded.IsZero() || time.Since(p.rootsLoaded) >= p.period {
+		p.roots, p.rootsErr = p.chainRoots.load(ctx, p.client)
+		p.rootsLoaded = time.Now()
+	}
+	return p.roots, p.rootsErr
+}
+
+// passwords returns the candidate passwords of the PKCS12 bundles and Java keystores of a secret: the one in the secret
+// referenced by its password-secret annotation, the one under the password key of the secret itself and those of the
+// checker's other password sources.
+func (p *PeriodicSecretChecker) passwords(ctx context.Context, secret *corev1.Secret) *exporters.Passwords {
+	var passwords exporters.Passwords
+	if name := secret.Annotations[PasswordSecretAnnotation]; name != "" {
+		key := secret.Annotations[PasswordSecretKeyAnnotation]
+		if key == "" {
+			key = p.passwordKey
+		}
+		password, err := p.referencedPassword(ctx, secret.Namespace, name, key)
+		if err != nil {
+			slog.Warn("Error reading password secret", "secret", secret.Name, "namespace", secret.Namespace, "password_secret", name, "error", err)
+			passwords.Err = err
+		} else {
+			passwords.Candidates = append(passwords.Candidates, password)
+		}
+	}
+	if password, found := secret.Data[p.passwordKey]; found && p.passwordKey != "" {
+		passwords.Candidates = append(passwords.Candidates, string(password))
+	}
+	// The passwords read so far were set for this secret, the sources for every secret of the checker
+	passwords.Specific = len(passwords.Candidates)
+	passwords.Add(p.passwordSources.Passwords(""))
+	return &passwords
+}
+
+// referencedPassword reads key from the named secret
+func (p *PeriodicSecretChecker) referencedPassword(ctx context.Context, namespace, name, key string) (string, error) {
+	data, err := p.referencedSecret(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	password, found := data[key]
+	if !found {
+		return "", fmt.Errorf("password secret %s has no key %s", name, key)
+	}
+	return string(password), nil
+}
+
+// cachedSecret is the data of a secret read by referencedSecret, kept for a polling period
+type cachedSecret struct {
+	data    map[string][]byte
+	err     error
+	fetched time.Time
+}
+
+// referencedSecret returns the data of the named secret from the informer caches if they hold it, or else from the
+// API server at most once per polling period.  The secret is listed by name rather than fetched, so the checker gets
+// along with the permission to list secrets it needs anyway.
+func (p *PeriodicSecretChecker) referencedSecret(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	key := namespace + "/" + name
+	stores, _ := p.stores()
+	if obj, ok := storesContain(stores, key); ok {
+		return obj.(*corev1.Secret).Data, nil
+	}
+
+	p.referencedMu.Lock()
+	cached, ok := p.referenced[key]
+	p.referencedMu.Unlock()
+	if ok && time.Since(cached.fetched) < p.period {
+		return cached.data, cached.err
+	}
+
+	cached = cachedSecret{err: fmt.Errorf("password secret %s not found", name), fetched: time.Now()}
+	secrets, err := p.client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String(),
+	})
+	if err != nil {
+		// API errors are not cached, the next secret retries
+		return nil, err
+	}
+	for _, s := range secrets.Items {
+		if s.Name == name {
+			cached = cachedSecret{data: s.Data, fetched: cached.fetched}
+		}
+	}
+
+	p.referencedMu.Lock()
+	defer p.referencedMu.Unlock()
+	if p.referenced == nil {
+		p.referenced = map[string]cachedSecret{}
+	}
+	for k, c := range p.referenced {
+		if time.Since(c.fetched) >= p.period {
+			delete(p.referenced, k)
+		}
 	}
+	p.referenced[key] = cached
+	return cached.data, cached.err
 }
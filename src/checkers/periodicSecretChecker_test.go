@@ -1,10 +1,25 @@
 package checkers
 
 import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
 	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
 )
 
 // Placeholder test - real Kubernetes integration tests are in integration_test.go
@@ -15,11 +30,22 @@ func TestPeriodicSecretChecker_Constructor(t *testing.T) {
 		[]string{"*.crt"},
 		[]string{},
 		[]string{"annotation=test"},
+		nil,
 		[]string{"default"},
 		[]string{},
-		"",
+		nil,
+		nil,
+		nil,
 		nil,
 		[]string{},
+		0,
+		0,
+		"password",
+		exporters.PasswordSources{},
+		false,
+		false,
+		ChainRoots{},
+		exporters.ExpiryThresholds{},
 	)
 
 	if checker == nil {
@@ -55,7 +81,8 @@ func TestNewSecretChecker_FullParameters(t *testing.T) {
 	annotationSelectors := []string{"cert-manager.io/certificate-name"}
 	namespaces := []string{"default", "test"}
 	nsLabelSelector := []string{"env=prod"}
-	kubeconfigPath := "/path/to/kubeconfig"
+	client := fake.NewSimpleClientset()
+	informers := kubeclient.NewInformers(client, nil)
 	exporter := &exporters.SecretExporter{}
 	includeTypes := []string{"kubernetes.io/tls"}
 
@@ -65,11 +92,22 @@ func TestNewSecretChecker_FullParameters(t *testing.T) {
 		includeGlobs,
 		excludeGlobs,
 		annotationSelectors,
+		nil,
 		namespaces,
 		nsLabelSelector,
-		kubeconfigPath,
+		nil,
+		client,
+		informers,
 		exporter,
 		includeTypes,
+		500,
+		4,
+		"password",
+		exporters.PasswordSources{},
+		false,
+		false,
+		ChainRoots{},
+		exporters.ExpiryThresholds{},
 	)
 
 	if checker == nil {
@@ -104,8 +142,12 @@ func TestNewSecretChecker_FullParameters(t *testing.T) {
 		t.Errorf("Expected %d nsLabelSelector, got %d", len(nsLabelSelector), len(checker.nsLabelSelector))
 	}
 
-	if checker.kubeconfigPath != kubeconfigPath {
-		t.Errorf("Expected kubeconfigPath %s, got %s", kubeconfigPath, checker.kubeconfigPath)
+	if checker.client != client {
+		t.Error("Expected client to match provided client")
+	}
+
+	if checker.informers != informers {
+		t.Error("Expected informers to match provided informers")
 	}
 
 	if checker.exporter != exporter {
@@ -124,11 +166,22 @@ func TestNewSecretChecker_EmptyParameters(t *testing.T) {
 		[]string{},
 		[]string{},
 		[]string{},
+		nil,
 		[]string{},
 		[]string{},
-		"",
+		nil,
+		nil,
+		nil,
 		&exporters.SecretExporter{},
 		[]string{},
+		0,
+		0,
+		"password",
+		exporters.PasswordSources{},
+		false,
+		false,
+		ChainRoots{},
+		exporters.ExpiryThresholds{},
 	)
 
 	if checker == nil {
@@ -147,3 +200,560 @@ func TestNewSecretChecker_EmptyParameters(t *testing.T) {
 		t.Error("Expected empty namespaces")
 	}
 }
+
+func TestPeriodicSecretChecker_Watch(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "watched", Organization: "test-org", Country: "US", Province: "CA", Days: 30,
+	})
+
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+			Data:       map[string][]byte{"tls.crt": cert.CertPEM, "tls.key": cert.PrivateKeyPEM},
+		},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, nil, []string{"*.crt"}, nil, nil, nil, []string{""}, nil, nil, client, kubeclient.NewInformers(client, stopCh), exporter, nil, 500, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+	checker.watch(context.Background())
+
+	waitForSecretSeries(t, testRegistry, "default/tls", 1)
+
+	_, err := client.CoreV1().Secrets("default").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "added", Namespace: "default"},
+		Data:       map[string][]byte{"ca.crt": cert.CertPEM},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create secret: %v", err)
+	}
+	waitForSecretSeries(t, testRegistry, "default/added", 1)
+
+	_, err = client.CoreV1().Secrets("default").Update(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "added", Namespace: "default"},
+		Data:       map[string][]byte{"ca.key": cert.PrivateKeyPEM},
+	}, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to update secret: %v", err)
+	}
+	waitForSecretSeries(t, testRegistry, "default/added", 0)
+
+	if err := client.CoreV1().Secrets("default").Delete(context.Background(), "tls", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete secret: %v", err)
+	}
+	waitForSecretSeries(t, testRegistry, "default/tls", 0)
+}
+
+func TestPeriodicSecretChecker_WatchReleasesInformers(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "watched", Days: 30})
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": cert.CertPEM},
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informers := kubeclient.NewInformers(client, stopCh)
+
+	// start runs a checker until its informers synced and returns the function stopping it, like the runner across a
+	// reload
+	start := func() (*PeriodicSecretChecker, func()) {
+		health := exporters.NewScanHealth("secrets", "secrets")
+		exporter := &exporters.SecretExporter{}
+		exporter.SetScanHealth(health)
+		checker := NewSecretChecker(time.Hour, nil, []string{"*.crt"}, nil, nil, nil, []string{""}, nil, nil, client, informers, exporter, nil, 500, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			checker.StartWatching(ctx)
+			close(done)
+		}()
+		for deadline := time.Now().Add(5 * time.Second); !health.Scanned() && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		}
+		return checker, func() {
+			cancel()
+			<-done
+		}
+	}
+
+	oldChecker, stopOld := start()
+	_, stopNew := start()
+
+	stopOld()
+	informer := oldChecker.registrations[0].informer
+	if informer.IsStopped() {
+		t.Error("Expected the shared informer to keep running for the new checker")
+	}
+
+	stopNew()
+	if !informer.IsStopped() {
+		t.Error("Expected the informer to stop once no checker uses it")
+	}
+}
+
+func TestPeriodicSecretChecker_WatchNamespaceLabelSelector(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "watched-ns", Organization: "test-org", Country: "US", Province: "CA", Days: 30,
+	})
+
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected", Labels: map[string]string{"certs": "true"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ignored"}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "selected"},
+			Data:       map[string][]byte{"tls.crt": cert.CertPEM},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "ignored"},
+			Data:       map[string][]byte{"tls.crt": cert.CertPEM},
+		},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, nil, []string{"*"}, nil, nil, nil, []string{""}, []string{"certs=true"}, nil, client, kubeclient.NewInformers(client, stopCh), exporter, nil, 500, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+	checker.watch(context.Background())
+
+	waitForSecretSeries(t, testRegistry, "selected/tls", 1)
+	waitForSecretSeries(t, testRegistry, "ignored/tls", 0)
+
+	if err := client.CoreV1().Namespaces().Delete(context.Background(), "selected", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete namespace: %v", err)
+	}
+	waitForSecretSeries(t, testRegistry, "selected/tls", 0)
+}
+
+func TestPeriodicSecretChecker_IncludeTypesFieldSelector(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "typed", Organization: "test-org", Country: "US", Province: "CA", Days: 30,
+	})
+
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": cert.CertPEM},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "default"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"tls.crt": cert.CertPEM},
+		},
+	)
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, []string{"app=web", ""}, []string{"*"}, nil, nil, nil, []string{"default"}, nil, nil, client, nil, exporter, []string{string(corev1.SecretTypeTLS), "example.com/cert"}, 100, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.StartChecking(ctx)
+
+	waitForSecretSeries(t, testRegistry, "default/tls", 1)
+	waitForSecretSeries(t, testRegistry, "default/opaque", 0)
+
+	var fieldSelectors []string
+	for _, action := range client.Actions() {
+		if list, ok := action.(k8stesting.ListAction); ok && action.GetResource().Resource == "secrets" {
+			fieldSelectors = append(fieldSelectors, list.GetListRestrictions().Fields.String())
+		}
+	}
+	slices.Sort(fieldSelectors)
+	want := []string{"type=example.com/cert", "type=example.com/cert", "type=kubernetes.io/tls", "type=kubernetes.io/tls"}
+	if !slices.Equal(fieldSelectors, want) {
+		t.Errorf("Expected a list per label selector and type, got field selectors %v", fieldSelectors)
+	}
+}
+
+func TestPeriodicSecretChecker_FailedScanKeepsSeries(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "kept", Days: 30})
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": cert.CertPEM},
+	})
+	var lists atomic.Int32
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if lists.Add(1) > 1 {
+			return true, nil, errors.New("api server unavailable")
+		}
+		return false, nil, nil
+	})
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(50*time.Millisecond, nil, []string{"*.crt"}, nil, nil, nil, []string{"default"}, nil, nil, client, nil, exporter, nil, 100, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.StartChecking(ctx)
+
+	waitForSecretSeries(t, testRegistry, "default/tls", 1)
+	// The third list starts after the second scan, whose list failed, has ended
+	for deadline := time.Now().Add(5 * time.Second); lists.Load() < 3 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+	}
+	if lists.Load() < 3 {
+		t.Fatal("Expected the checker to keep scanning after a failed list")
+	}
+	waitForSecretSeries(t, testRegistry, "default/tls", 1)
+}
+
+// waitForSecretSeries polls the registry until the secret identified by namespace/name has the expected
+// number of cert_exporter_secret_expires_in_seconds series.
+func waitForSecretSeries(t *testing.T, registry *prometheus.Registry, secret string, want int) {
+	t.Helper()
+
+	var got int
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+
+		got = 0
+		for _, mf := range mfs {
+			if mf.GetName() != "cert_exporter_secret_expires_in_seconds" {
+				continue
+			}
+			for _, metric := range mf.GetMetric() {
+				var name, namespace string
+				for _, label := range metric.GetLabel() {
+					switch label.GetName() {
+					case "secret_name":
+						name = label.GetValue()
+					case "secret_namespace":
+						namespace = label.GetValue()
+					}
+				}
+				if namespace+"/"+name == secret {
+					got++
+				}
+			}
+		}
+		if got == want {
+			return
+		}
+	}
+	t.Fatalf("Expected %d series for secret %s, got %d", want, secret, got)
+}
+
+func TestPeriodicSecretChecker_LabelsToMetric(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "labelled", Organization: "test-org", Country: "US", Province: "CA", Days: 30,
+	})
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, nil, []string{"*.crt"}, nil, nil, []string{"team", "app.kubernetes.io/name", "annotation:example.com/owner"}, []string{""}, nil, nil, nil, nil, exporter, nil, 500, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "tls",
+			Namespace:   "default",
+			Labels:      map[string]string{"team": "payments", "app.kubernetes.io/name": "checkout"},
+			Annotations: map[string]string{"example.com/owner": "alice"},
+		},
+		Data: map[string][]byte{"tls.crt": cert.CertPEM},
+	})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default", Labels: map[string]string{"team": "search"}},
+		Data:       map[string][]byte{"tls.key": cert.PrivateKeyPEM},
+	})
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	var got []map[string]string
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_secret_labels" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			got = append(got, labels)
+		}
+	}
+
+	want := map[string]string{
+		"secret_name":                  "tls",
+		"secret_namespace":             "default",
+		"label_team":                   "payments",
+		"label_app_kubernetes_io_name": "checkout",
+		"annotation_example_com_owner": "alice",
+	}
+	if len(got) != 1 || !maps.Equal(got[0], want) {
+		t.Errorf("Expected a single secret_labels series %v, got %v", want, got)
+	}
+}
+
+func TestPeriodicSecretChecker_PasswordKey(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "java", Days: 30})
+	keystore := testutil.CreateKeystore(t, false, "changeit", testutil.KeystoreEntry{Alias: "server", Certs: []*testutil.CertBundle{cert}, PrivateKey: true})
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, nil, []string{"*.jks"}, nil, nil, nil, []string{""}, nil, nil, nil, nil, exporter, nil, 500, 4, "keystore-password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "java", Namespace: "default"},
+		Data: map[string][]byte{
+			"keystore.jks":      keystore,
+			"keystore-password": []byte("changeit"),
+			"password":          []byte("not-the-keystore-password"),
+		},
+	})
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := false
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_secret_cert_info" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["key_name"] == "keystore.jks" && labels["alias"] == "server" && labels["cn"] == "java" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected secret_cert_info for the keystore entry verified with the keystore-password key")
+	}
+}
+
+func TestPeriodicSecretChecker_PasswordSecretAnnotation(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "pkcs12", Days: 30})
+	pfxData := testutil.CreatePKCS12Bundle(t, cert, nil, "s3cret")
+
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+			Data:       map[string][]byte{"pass": []byte("not-the-bundle-password")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bundle-password", Namespace: "default"},
+			Data:       map[string][]byte{"pass": []byte("s3cret")},
+		},
+	)
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, nil, []string{"*.p12"}, nil, nil, nil, []string{""}, nil, nil, client, nil, exporter, nil, 500, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bundle",
+			Namespace: "default",
+			Annotations: map[string]string{
+				PasswordSecretAnnotation:    "bundle-password",
+				PasswordSecretKeyAnnotation: "pass",
+			},
+		},
+		Data: map[string][]byte{"bundle.p12": pfxData},
+	})
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := false
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "cert_exporter_secret_cert_info":
+			for _, metric := range mf.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "cn" && label.GetValue() == "pkcs12" {
+						found = true
+					}
+				}
+			}
+		case "cert_exporter_password_failed":
+			t.Errorf("Expected no password_failed series, got %d", len(mf.GetMetric()))
+		}
+	}
+	if !found {
+		t.Error("Expected secret_cert_info for the bundle decrypted with the password of the annotated secret")
+	}
+}
+
+func TestPeriodicSecretChecker_PasswordSecretCached(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "pkcs12", Days: 30})
+	pfxData := testutil.CreatePKCS12Bundle(t, cert, nil, "s3cret")
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bundle-password", Namespace: "default"},
+		Data:       map[string][]byte{"pass": []byte("s3cret")},
+	})
+	lists := 0
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists++
+		return false, nil, nil
+	})
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, nil, []string{"*.p12"}, nil, nil, nil, []string{""}, nil, nil, client, nil, exporter, nil, 500, 4, "password", exporters.PasswordSources{}, false, false, ChainRoots{}, exporters.ExpiryThresholds{})
+	for _, name := range []string{"first", "second"} {
+		checker.exportSecret(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Annotations: map[string]string{
+					PasswordSecretAnnotation:    "bundle-password",
+					PasswordSecretKeyAnnotation: "pass",
+				},
+			},
+			Data: map[string][]byte{"bundle.p12": pfxData},
+		})
+	}
+
+	if lists != 1 {
+		t.Errorf("Expected the password secret to be listed once for both bundles, got %d lists", lists)
+	}
+}
+
+func TestPeriodicSecretChecker_CheckKeypair(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "tls", Days: 30})
+	other := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "other", Days: 30})
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	checker := NewSecretChecker(time.Hour, nil, []string{"*.crt"}, nil, nil, nil, []string{""}, nil, nil, nil, nil, exporter, nil, 500, 4, "password", exporters.PasswordSources{}, true, false, ChainRoots{}, exporters.ExpiryThresholds{})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rotated", Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: other.CertPEM, corev1.TLSPrivateKeyKey: cert.PrivateKeyPEM},
+	})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "default"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{corev1.TLSCertKey: other.CertPEM, corev1.TLSPrivateKeyKey: cert.PrivateKeyPEM},
+	})
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	var got []map[string]string
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_secret_keypair_match" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			got = append(got, labels)
+		}
+	}
+
+	want := map[string]string{"secret_name": "rotated", "secret_namespace": "default", "reason": exporters.KeypairReasonMismatch}
+	if len(got) != 1 || !maps.Equal(got[0], want) {
+		t.Errorf("Expected a single secret_keypair_match series %v, got %v", want, got)
+	}
+}
+
+func TestPeriodicSecretChecker_VerifyChain(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root", Days: 3650, IsCA: true})
+	intermediate := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "intermediate", Days: 365, IsCA: true}, root)
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "leaf", Days: 30}, intermediate)
+
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "trust-bundle", Namespace: "cert-manager"},
+		Data:       map[string]string{"ca.crt": string(root.CertPEM)},
+	})
+
+	exporter := &exporters.SecretExporter{}
+	exporter.ResetMetrics()
+	chainRoots := ChainRoots{ConfigMap: "cert-manager/trust-bundle"}
+	checker := NewSecretChecker(time.Hour, nil, []string{"*.crt"}, nil, nil, nil, []string{""}, nil, nil, client, nil, exporter, nil, 500, 4, "password", exporters.PasswordSources{}, false, true, chainRoots, exporters.ExpiryThresholds{})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "complete", Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: testutil.CreateCertBundle(leaf, intermediate)},
+	})
+	checker.exportSecret(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "incomplete", Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: leaf.CertPEM},
+	})
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_secret_chain_valid" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			got[labels["secret_name"]] = labels["reason"]
+		}
+	}
+
+	want := map[string]string{"complete": exporters.ChainReasonValid, "incomplete": exporters.ChainReasonUnknownAuthority}
+	if !maps.Equal(got, want) {
+		t.Errorf("Expected secret_chain_valid reasons %v, got %v", want, got)
+	}
+
+	permission := kubeclient.Permission{Verb: "list", Resource: "configmaps", Namespace: "cert-manager"}
+	if !slices.Contains(checker.Permissions(false), permission) || !slices.Contains(checker.Permissions(true), permission) {
+		t.Errorf("Expected the checker to ask for %v", permission)
+	}
+}
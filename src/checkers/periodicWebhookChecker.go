@@ -8,10 +8,9 @@ import (
 	v1 "k8s.io/api/admissionregistration/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/joe-elliott/cert-exporter/src/exporters"
-	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
 )
 
 const (
@@ -23,54 +22,64 @@ const (
 type PeriodicWebhookChecker struct {
 	period              time.Duration
 	labelSelectors      []string
-	kubeconfigPath      string
+	client              kubernetes.Interface
 	annotationSelectors []string
+	labelsToMetric      []string
 	exporter            *exporters.WebhookExporter
 }
 
 // NewWebhookChecker is a factory method that returns a new PeriodicNewWebhookChecker
-func NewWebhookChecker(period time.Duration, labelSelectors, annotationSelectors []string, kubeconfigPath string, e *exporters.WebhookExporter) *PeriodicWebhookChecker {
+func NewWebhookChecker(period time.Duration, labelSelectors, annotationSelectors, labelsToMetric []string, client kubernetes.Interface, e *exporters.WebhookExporter) *PeriodicWebhookChecker {
 	return &PeriodicWebhookChecker{
 		period:              period,
 		labelSelectors:      labelSelectors,
 		annotationSelectors: annotationSelectors,
-		kubeconfigPath:      kubeconfigPath,
+		labelsToMetric:      labelsToMetric,
+		client:              client,
 		exporter:            e,
 	}
 }
 
-// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.
-func (p *PeriodicWebhookChecker) StartChecking() {
-	config, err := clientcmd.BuildConfigFromFlags("", p.kubeconfigPath)
-	if err != nil {
-		slog.Error("Error building kubeconfig", "error", err)
-	}
-
-	// creates the clientset
-	client, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		slog.Error("kubernetes.NewForConfig failed", "error", err)
+// Permissions returns the requests the checker makes against the API server
+func (p *PeriodicWebhookChecker) Permissions() []kubeclient.Permission {
+	return []kubeclient.Permission{
+		{Verb: "list", Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations"},
+		{Verb: "list", Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations"},
 	}
+}
 
+// StartChecking starts the periodic file check.  Most likely you want to run this as an independent go routine.  It returns once ctx is cancelled.
+func (p *PeriodicWebhookChecker) StartChecking(ctx context.Context) {
 	periodChannel := time.Tick(p.period)
 
 	for {
 		slog.Info("Begin periodic check")
 
-		p.exporter.ResetMetrics()
-		p.checkMutatingWebhook(client)
-		p.checkValidatingWebhook(client)
-		<-periodChannel
+		p.exporter.BeginScan()
+		scanCtx, cancel := newScanContext(ctx, p.period)
+		p.checkMutatingWebhook(scanCtx, p.client)
+		p.checkValidatingWebhook(scanCtx, p.client)
+		endScan(scanCtx, p.exporter)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-periodChannel:
+		}
 	}
 }
 
-func (p *PeriodicWebhookChecker) checkMutatingWebhook(client kubernetes.Interface) {
+func (p *PeriodicWebhookChecker) checkMutatingWebhook(ctx context.Context, client kubernetes.Interface) {
 	var configs []v1.MutatingWebhookConfiguration
 	var err error
 	if len(p.labelSelectors) > 0 {
 		for _, labelSelector := range p.labelSelectors {
 			var m *v1.MutatingWebhookConfigurationList
-			m, err = client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{
+			m, err = client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{
 				LabelSelector: labelSelector,
 			})
 			if err != nil {
@@ -81,7 +90,7 @@ func (p *PeriodicWebhookChecker) checkMutatingWebhook(client kubernetes.Interfac
 		}
 	} else {
 		var m *v1.MutatingWebhookConfigurationList
-		m, err = client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
+		m, err = client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
 		if err == nil {
 			configs = m.Items
 		}
@@ -89,51 +98,46 @@ func (p *PeriodicWebhookChecker) checkMutatingWebhook(client kubernetes.Interfac
 
 	if err != nil {
 		slog.Error("Error requesting mutatingwebhookconfiguration", "error", err)
-		metrics.ErrorTotal.Inc()
+		p.exporter.ScanError(exporters.ReasonAPIError)
 		return
 	}
 
 	for _, configuration := range configs {
+		p.exporter.ObjectScanned()
 		slog.Info("Reviewing mutatingwebhookconfiguration", "name", configuration.GetName())
-		if len(p.annotationSelectors) > 0 {
-			matches := false
-			annotations := configuration.GetAnnotations()
-			for _, selector := range p.annotationSelectors {
-				_, ok := annotations[selector]
-				if ok {
-					matches = true
-					break
-				}
-			}
-
-			if !matches {
-				continue
-			}
+		if !matchAnnotations(p.annotationSelectors, configuration.GetAnnotations()) {
+			continue
 		}
 		slog.Info("Annotations matched. Parsing mutatingwebhookconfiguration.")
+		published := false
 
 		for _, admissionReviewVersions := range configuration.Webhooks {
 			if len(admissionReviewVersions.ClientConfig.CABundle) > 0 {
 				slog.Info("Publishing metrics", "name", configuration.Name)
+				published = true
 				err = p.exporter.ExportMetrics(admissionReviewVersions.ClientConfig.CABundle, mutatingWebhookConfigurationType, configuration.Name, admissionReviewVersions.Name)
 				if err != nil {
 					slog.Error("Error exporting mutatingwebhookconfiguration", "error", err)
-					metrics.ErrorTotal.Inc()
+					p.exporter.ScanError(exporters.ReasonParseError)
 				}
 			} else {
 				slog.Info("Ignoring - no CABundle cert", "name", configuration.Name)
 			}
 		}
+		if published && len(p.labelsToMetric) > 0 {
+			names, values := objectMetricLabels(p.labelsToMetric, &configuration)
+			p.exporter.ExportLabels(names, values, mutatingWebhookConfigurationType, configuration.Name)
+		}
 	}
 }
 
-func (p *PeriodicWebhookChecker) checkValidatingWebhook(client kubernetes.Interface) {
+func (p *PeriodicWebhookChecker) checkValidatingWebhook(ctx context.Context, client kubernetes.Interface) {
 	var configs []v1.ValidatingWebhookConfiguration
 	var err error
 	if len(p.labelSelectors) > 0 {
 		for _, labelSelector := range p.labelSelectors {
 			var v *v1.ValidatingWebhookConfigurationList
-			v, err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{
+			v, err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{
 				LabelSelector: labelSelector,
 			})
 			if err != nil {
@@ -144,7 +148,7 @@ func (p *PeriodicWebhookChecker) checkValidatingWebhook(client kubernetes.Interf
 		}
 	} else {
 		var v *v1.ValidatingWebhookConfigurationList
-		v, err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{})
+		v, err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
 		if err == nil {
 			configs = v.Items
 		}
@@ -152,40 +156,35 @@ func (p *PeriodicWebhookChecker) checkValidatingWebhook(client kubernetes.Interf
 
 	if err != nil {
 		slog.Error("Error requesting validatingwebhookconfiguration", "error", err)
-		metrics.ErrorTotal.Inc()
+		p.exporter.ScanError(exporters.ReasonAPIError)
 		return
 	}
 
 	for _, configuration := range configs {
+		p.exporter.ObjectScanned()
 		slog.Info("Reviewing validatingwebhookconfiguration", "name", configuration.GetName())
-		if len(p.annotationSelectors) > 0 {
-			matches := false
-			annotations := configuration.GetAnnotations()
-			for _, selector := range p.annotationSelectors {
-				_, ok := annotations[selector]
-				if ok {
-					matches = true
-					break
-				}
-			}
-
-			if !matches {
-				continue
-			}
+		if !matchAnnotations(p.annotationSelectors, configuration.GetAnnotations()) {
+			continue
 		}
 		slog.Info("Annotations matched. Parsing validatingwebhookconfiguration.")
+		published := false
 
 		for _, admissionReviewVersions := range configuration.Webhooks {
 			if len(admissionReviewVersions.ClientConfig.CABundle) > 0 {
 				slog.Info("Publishing metrics", "name", configuration.Name)
+				published = true
 				err = p.exporter.ExportMetrics(admissionReviewVersions.ClientConfig.CABundle, validatingWebhookConfigurationType, configuration.Name, admissionReviewVersions.Name)
 				if err != nil {
 					slog.Error("Error exporting validatingwebhookconfiguration", "error", err)
-					metrics.ErrorTotal.Inc()
+					p.exporter.ScanError(exporters.ReasonParseError)
 				}
 			} else {
 				slog.Info("Ignoring - no CABundle cert", "name", configuration.Name)
 			}
 		}
+		if published && len(p.labelsToMetric) > 0 {
+			names, values := objectMetricLabels(p.labelsToMetric, &configuration)
+			p.exporter.ExportLabels(names, values, validatingWebhookConfigurationType, configuration.Name)
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package checkers
 
 import (
+	"k8s.io/client-go/kubernetes/fake"
 	"testing"
 	"time"
 
@@ -11,10 +12,10 @@ func TestNewWebhookChecker(t *testing.T) {
 	period := 5 * time.Minute
 	labelSelectors := []string{"app=webhook"}
 	annotationSelectors := []string{"webhook.cert-manager.io/inject-ca-from"}
-	kubeconfigPath := "/path/to/kubeconfig"
+	client := fake.NewSimpleClientset()
 	exporter := &exporters.WebhookExporter{}
 
-	checker := NewWebhookChecker(period, labelSelectors, annotationSelectors, kubeconfigPath, exporter)
+	checker := NewWebhookChecker(period, labelSelectors, annotationSelectors, nil, client, exporter)
 
 	if checker == nil {
 		t.Fatal("Expected NewWebhookChecker to return non-nil checker")
@@ -32,8 +33,8 @@ func TestNewWebhookChecker(t *testing.T) {
 		t.Errorf("Expected %d annotationSelectors, got %d", len(annotationSelectors), len(checker.annotationSelectors))
 	}
 
-	if checker.kubeconfigPath != kubeconfigPath {
-		t.Errorf("Expected kubeconfigPath '%s', got '%s'", kubeconfigPath, checker.kubeconfigPath)
+	if checker.client != client {
+		t.Error("Expected client to match provided client")
 	}
 
 	if checker.exporter != exporter {
@@ -42,7 +43,7 @@ func TestNewWebhookChecker(t *testing.T) {
 }
 
 func TestNewWebhookChecker_EmptyParameters(t *testing.T) {
-	checker := NewWebhookChecker(time.Second, []string{}, []string{}, "", nil)
+	checker := NewWebhookChecker(time.Second, []string{}, []string{}, nil, nil, nil)
 
 	if checker == nil {
 		t.Fatal("Expected NewWebhookChecker to return non-nil checker")
@@ -65,7 +66,8 @@ func TestNewWebhookChecker_MultipleSelectors(t *testing.T) {
 		10*time.Second,
 		labelSelectors,
 		annotationSelectors,
-		"/etc/kubeconfig",
+		nil,
+		nil,
 		&exporters.WebhookExporter{},
 	)
 
@@ -0,0 +1,15 @@
+package checkers
+
+import (
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
+)
+
+// namespacedPermissions returns the permission to make a request in each of the namespaces.  An empty namespace
+// stands for all namespaces.
+func namespacedPermissions(verb, group, resource string, namespaces []string) []kubeclient.Permission {
+	var permissions []kubeclient.Permission
+	for _, ns := range namespaces {
+		permissions = append(permissions, kubeclient.Permission{Verb: verb, Group: group, Resource: resource, Namespace: ns})
+	}
+	return permissions
+}
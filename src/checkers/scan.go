@@ -0,0 +1,115 @@
+package checkers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newScanContext returns the context for a single scan.  A scan is cut off once it takes longer than the polling
+// period, so a hung API call cannot keep a checker from ever scanning again.
+func newScanContext(ctx context.Context, period time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, period)
+}
+
+// scanEnder finishes the scan of an exporter
+type scanEnder interface {
+	EndScan()
+	AbortScan()
+	ScanFailed() bool
+}
+
+// endScan publishes the scan, unless an API call failed or scanCtx ran out before it was done.  The previous scan is
+// kept then, as the series of the objects the scan missed would be deleted otherwise.
+func endScan(scanCtx context.Context, e scanEnder) {
+	if e.ScanFailed() || scanCtx.Err() != nil {
+		e.AbortScan()
+		return
+	}
+	e.EndScan()
+}
+
+// listPages calls list for one page of at most pageSize objects after another, following the continue token of each
+// page, and hands every page to page.  Only one page is held in memory at a time.  Objects of the pages before an
+// error have already been handed to page.
+func listPages[L interface{ GetContinue() string }](ctx context.Context, options metav1.ListOptions, pageSize int64, list func(context.Context, metav1.ListOptions) (L, error), page func(L)) error {
+	options.Limit = pageSize
+	for {
+		result, err := list(ctx, options)
+		if err != nil {
+			return err
+		}
+		page(result)
+
+		options.Continue = result.GetContinue()
+		if options.Continue == "" {
+			return nil
+		}
+	}
+}
+
+// namespacedList is a list call of a scan: the objects in namespace matching options
+type namespacedList struct {
+	namespace string
+	options   metav1.ListOptions
+}
+
+// namespacedLists returns a list per namespace and options
+func namespacedLists(namespaces []string, options []metav1.ListOptions) []namespacedList {
+	var lists []namespacedList
+	for _, ns := range namespaces {
+		for _, o := range options {
+			lists = append(lists, namespacedList{namespace: ns, options: o})
+		}
+	}
+	return lists
+}
+
+// labelSelectorOptions returns list options per label selector, or a single list option without selector if there are
+// none
+func labelSelectorOptions(labelSelectors []string) []metav1.ListOptions {
+	if len(labelSelectors) == 0 {
+		return []metav1.ListOptions{{}}
+	}
+
+	options := make([]metav1.ListOptions, len(labelSelectors))
+	for i, labelSelector := range labelSelectors {
+		options[i] = metav1.ListOptions{LabelSelector: labelSelector}
+	}
+	return options
+}
+
+// forEach calls fn for every item with at most concurrency calls running at once and returns once every call returned
+func forEach[T any](items []T, concurrency int, fn func(T)) {
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for _, item := range items {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			fn(item)
+		})
+	}
+	wg.Wait()
+}
+
+// workers starts concurrency goroutines calling fn for every item sent on the returned channel.  The returned function
+// closes the channel and waits until every item sent has been handled.
+func workers[T any](concurrency int, fn func(T)) (chan<- T, func()) {
+	items := make(chan T, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Go(func() {
+			for item := range items {
+				fn(item)
+			}
+		})
+	}
+
+	return items, func() {
+		close(items)
+		wg.Wait()
+	}
+}
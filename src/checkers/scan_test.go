@@ -0,0 +1,115 @@
+package checkers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestListPages(t *testing.T) {
+	pages := map[string]*corev1.SecretList{
+		"":       {ListMeta: metav1.ListMeta{Continue: "page-2"}, Items: []corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "b"}}}},
+		"page-2": {ListMeta: metav1.ListMeta{Continue: "page-3"}, Items: []corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "c"}}, {ObjectMeta: metav1.ObjectMeta{Name: "d"}}}},
+		"page-3": {Items: []corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "e"}}}},
+	}
+
+	list := func(_ context.Context, options metav1.ListOptions) (*corev1.SecretList, error) {
+		if options.Limit != 2 {
+			t.Errorf("Expected a limit of 2, got %d", options.Limit)
+		}
+		if options.LabelSelector != "app=web" {
+			t.Errorf("Expected the label selector to be kept on every page, got %q", options.LabelSelector)
+		}
+		return pages[options.Continue], nil
+	}
+
+	var names []string
+	var pageCount int
+	err := listPages(context.Background(), metav1.ListOptions{LabelSelector: "app=web"}, 2, list, func(s *corev1.SecretList) {
+		pageCount++
+		for _, secret := range s.Items {
+			names = append(names, secret.Name)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to list pages: %v", err)
+	}
+	if pageCount != 3 || len(names) != 5 {
+		t.Errorf("Expected 5 secrets in 3 pages, got %v in %d pages", names, pageCount)
+	}
+}
+
+func TestListPages_Error(t *testing.T) {
+	listErr := errors.New("the provided continue parameter is too old")
+
+	var pageCount int
+	err := listPages(context.Background(), metav1.ListOptions{}, 1, func(_ context.Context, options metav1.ListOptions) (*corev1.SecretList, error) {
+		if options.Continue != "" {
+			return nil, listErr
+		}
+		return &corev1.SecretList{ListMeta: metav1.ListMeta{Continue: "page-2"}}, nil
+	}, func(*corev1.SecretList) { pageCount++ })
+
+	if !errors.Is(err, listErr) {
+		t.Errorf("Expected the list error, got %v", err)
+	}
+	if pageCount != 1 {
+		t.Errorf("Expected the first page to be handled before the error, got %d pages", pageCount)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	seen := map[int]bool{}
+
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+
+	forEach(items, 3, func(i int) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+
+		mu.Lock()
+		seen[i] = true
+		mu.Unlock()
+	})
+
+	if len(seen) != len(items) {
+		t.Errorf("Expected every item to be handled, got %d of %d", len(seen), len(items))
+	}
+	if got := peak.Load(); got > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", got)
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	var handled atomic.Int32
+	items, wait := workers(4, func(n int) {
+		handled.Add(int32(n))
+	})
+
+	for i := 1; i <= 100; i++ {
+		items <- i
+	}
+	wait()
+
+	if got := handled.Load(); got != 5050 {
+		t.Errorf("Expected every item to be handled before wait returns, got sum %d", got)
+	}
+}
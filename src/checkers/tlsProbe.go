@@ -0,0 +1,143 @@
+package checkers
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	startTLSSMTP     = "smtp"
+	startTLSIMAP     = "imap"
+	startTLSPostgres = "postgres"
+)
+
+// postgresSSLRequestCode is the magic protocol version a postgres client sends to ask for TLS.
+const postgresSSLRequestCode = 80877103
+
+// probeTLS connects to address, optionally upgrades the connection with STARTTLS and performs a TLS
+// handshake.  It returns the certificate chain presented by the server and the duration of the
+// handshake itself.  The chain is not verified, cert-exporter reports on it whether it is trusted or not.
+func probeTLS(ctx context.Context, address, serverName, startTLS string, timeout time.Duration) ([]*x509.Certificate, time.Duration, error) {
+	deadline := time.Now().Add(timeout)
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, 0, err
+	}
+
+	if err := negotiateStartTLS(conn, startTLS); err != nil {
+		return nil, 0, fmt.Errorf("%s starttls failed: %w", startTLS, err)
+	}
+
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(address)
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+
+	start := time.Now()
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, 0, err
+	}
+	duration := time.Since(start)
+
+	return tlsConn.ConnectionState().PeerCertificates, duration, nil
+}
+
+// negotiateStartTLS speaks enough of the given plaintext protocol to have the server switch to TLS.
+func negotiateStartTLS(conn net.Conn, protocol string) error {
+	switch protocol {
+	case "":
+		return nil
+	case startTLSSMTP:
+		reader := bufio.NewReader(conn)
+		if err := readSMTPResponse(reader, "220"); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(conn, "EHLO cert-exporter\r\n"); err != nil {
+			return err
+		}
+		if err := readSMTPResponse(reader, "250"); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(conn, "STARTTLS\r\n"); err != nil {
+			return err
+		}
+		return readSMTPResponse(reader, "220")
+	case startTLSIMAP:
+		reader := bufio.NewReader(conn)
+		greeting, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(greeting, "* OK") {
+			return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(greeting))
+		}
+		if _, err := io.WriteString(conn, "a001 STARTTLS\r\n"); err != nil {
+			return err
+		}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(line, "a001 ") {
+				if !strings.HasPrefix(line, "a001 OK") {
+					return fmt.Errorf("unexpected response %q", strings.TrimSpace(line))
+				}
+				return nil
+			}
+		}
+	case startTLSPostgres:
+		request := make([]byte, 8)
+		binary.BigEndian.PutUint32(request[0:4], 8)
+		binary.BigEndian.PutUint32(request[4:8], postgresSSLRequestCode)
+		if _, err := conn.Write(request); err != nil {
+			return err
+		}
+		response := make([]byte, 1)
+		if _, err := io.ReadFull(conn, response); err != nil {
+			return err
+		}
+		if response[0] != 'S' {
+			return fmt.Errorf("server does not support TLS")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
+
+// readSMTPResponse reads a possibly multi-line SMTP response and checks its status code.
+func readSMTPResponse(reader *bufio.Reader, code string) error {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, code) {
+			return fmt.Errorf("unexpected response %q", strings.TrimSpace(line))
+		}
+		// "250-" continues a multi-line response, "250 " ends it
+		if len(line) < 4 || line[3] != '-' {
+			return nil
+		}
+	}
+}
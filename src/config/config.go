@@ -0,0 +1,521 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/joe-elliott/cert-exporter/src/checkers"
+)
+
+const (
+	// DefaultPollingPeriod is used by checkers that set neither their own nor a global polling period
+	DefaultPollingPeriod = time.Hour
+	// DefaultTLSEndpointTimeout is used by endpoint and ingress checkers that do not set a timeout
+	DefaultTLSEndpointTimeout = 10 * time.Second
+	// DefaultAwsKeySubString is used by AWS checkers that do not set a key substring
+	DefaultAwsKeySubString = ".pem"
+	// DefaultListPageSize is used by secret and configmap checkers that set neither their own nor a global page size
+	DefaultListPageSize = 500
+	// DefaultScanConcurrency is used by secret and configmap checkers that set neither their own nor a global
+	// concurrency
+	DefaultScanConcurrency = 4
+	// DefaultWarnBefore is used by secret, configmap and certrequest checkers that set neither their own nor a global
+	// warn-before threshold
+	DefaultWarnBefore = 30 * 24 * time.Hour
+	// DefaultCriticalBefore is used by secret, configmap and certrequest checkers that set neither their own nor a
+	// global critical-before threshold
+	DefaultCriticalBefore = 7 * 24 * time.Hour
+	// DefaultPasswordKey is the secret data key holding the password of PKCS12 bundles and Java keystores of secret
+	// checkers that do not set their own
+	DefaultPasswordKey = "password"
+)
+
+// Config describes every checker cert-exporter runs.  It is read from the file passed with --config, which may be YAML
+// or JSON, and is merged with the checkers configured by command line flags.
+type Config struct {
+	PollingPeriod time.Duration `yaml:"polling-period"`
+	Kubeconfig    string        `yaml:"kubeconfig"`
+	// ListPageSize is the number of objects requested per page when listing secrets and configmaps
+	ListPageSize int64 `yaml:"list-page-size"`
+	// ScanConcurrency is the number of list calls and the number of objects parsed at once by a secret or configmap scan
+	ScanConcurrency int `yaml:"scan-concurrency"`
+	// Thresholds are the default expiry thresholds of secret, configmap and certrequest checkers
+	Thresholds `yaml:",inline"`
+
+	Certs        []FileConfig        `yaml:"certs"`
+	KubeConfigs  []FileConfig        `yaml:"kubeconfigs"`
+	Secrets      []SecretConfig      `yaml:"secrets"`
+	ConfigMaps   []ConfigMapConfig   `yaml:"configmaps"`
+	Webhooks     []WebhookConfig     `yaml:"webhooks"`
+	CertRequests []CertRequestConfig `yaml:"certrequests"`
+	Certificates []CertificateConfig `yaml:"certificates"`
+	Aws          []AwsConfig         `yaml:"aws"`
+	TLSEndpoints []TLSEndpointConfig `yaml:"tls-endpoints"`
+	Ingresses    []IngressConfig     `yaml:"ingresses"`
+}
+
+// Common holds the settings shared by every checker
+type Common struct {
+	// Name identifies the checker in logs and errors.  It defaults to the section name and index, e.g. secrets[0].
+	Name          string        `yaml:"name"`
+	PollingPeriod time.Duration `yaml:"polling-period"`
+}
+
+// Thresholds holds the expiry thresholds of cert_status: certs expiring within critical-before are critical, those
+// expiring within warn-before a warning.  Objects may override them with the cert-exporter.io/warn-before and
+// cert-exporter.io/critical-before annotations.
+type Thresholds struct {
+	WarnBefore     time.Duration `yaml:"warn-before"`
+	CriticalBefore time.Duration `yaml:"critical-before"`
+}
+
+// PasswordSources configure the passwords tried for PKCS12 bundles and Java keystores after the ones specific to the
+// checker
+type PasswordSources struct {
+	// PasswordEnv is the environment variable holding a password
+	PasswordEnv string `yaml:"password-env"`
+	// CandidatePasswords are tried after every other password
+	CandidatePasswords []string `yaml:"passwords"`
+}
+
+// FileConfig configures a checker for certs or kubeconfigs on disk
+type FileConfig struct {
+	Common          `yaml:",inline"`
+	PasswordSources `yaml:",inline"`
+	IncludeGlobs    []string `yaml:"include-globs"`
+	ExcludeGlobs    []string `yaml:"exclude-globs"`
+	// PasswordFile holds the password of the certs checker's PKCS12 bundles and Java keystores.  A relative path is
+	// resolved against the directory of each cert.
+	PasswordFile string `yaml:"password-file"`
+}
+
+// SecretConfig configures a kubernetes secret checker
+type SecretConfig struct {
+	Common                  `yaml:",inline"`
+	Thresholds              `yaml:",inline"`
+	PasswordSources         `yaml:",inline"`
+	Kubeconfig              string   `yaml:"kubeconfig"`
+	LabelSelectors          []string `yaml:"label-selectors"`
+	AnnotationSelectors     []string `yaml:"annotation-selectors"`
+	LabelsToMetric          []string `yaml:"labels-to-metric"`
+	Namespaces              []string `yaml:"namespaces"`
+	ExcludeNamespaces       []string `yaml:"exclude-namespaces"`
+	NamespaceLabelSelectors []string `yaml:"namespace-label-selectors"`
+	IncludeGlobs            []string `yaml:"include-globs"`
+	ExcludeGlobs            []string `yaml:"exclude-globs"`
+	IncludeTypes            []string `yaml:"include-types"`
+	Watch                   bool     `yaml:"watch"`
+	ListPageSize            int64    `yaml:"list-page-size"`
+	ScanConcurrency         int      `yaml:"scan-concurrency"`
+	// PasswordKey is the data key of the secret holding the password of its PKCS12 bundles and Java keystores
+	PasswordKey string `yaml:"password-key"`
+	// CheckKeypair checks the private key of kubernetes.io/tls secrets against their cert
+	CheckKeypair bool `yaml:"check-keypair"`
+	// VerifyChain verifies the cert chain of kubernetes.io/tls secrets against the chain roots, the system roots unless
+	// a file or a namespace/name configmap is set
+	VerifyChain         bool   `yaml:"verify-chain"`
+	ChainRootsFile      string `yaml:"chain-roots-file"`
+	ChainRootsConfigMap string `yaml:"chain-roots-configmap"`
+}
+
+// ConfigMapConfig configures a kubernetes configmap checker
+type ConfigMapConfig struct {
+	Common                  `yaml:",inline"`
+	Thresholds              `yaml:",inline"`
+	Kubeconfig              string   `yaml:"kubeconfig"`
+	LabelSelectors          []string `yaml:"label-selectors"`
+	AnnotationSelectors     []string `yaml:"annotation-selectors"`
+	LabelsToMetric          []string `yaml:"labels-to-metric"`
+	Namespaces              []string `yaml:"namespaces"`
+	ExcludeNamespaces       []string `yaml:"exclude-namespaces"`
+	NamespaceLabelSelectors []string `yaml:"namespace-label-selectors"`
+	IncludeGlobs            []string `yaml:"include-globs"`
+	ExcludeGlobs            []string `yaml:"exclude-globs"`
+	ListPageSize            int64    `yaml:"list-page-size"`
+	ScanConcurrency         int      `yaml:"scan-concurrency"`
+}
+
+// WebhookConfig configures an admission webhook checker
+type WebhookConfig struct {
+	Common              `yaml:",inline"`
+	Kubeconfig          string   `yaml:"kubeconfig"`
+	LabelSelectors      []string `yaml:"label-selectors"`
+	AnnotationSelectors []string `yaml:"annotation-selectors"`
+	LabelsToMetric      []string `yaml:"labels-to-metric"`
+}
+
+// CertRequestConfig configures a cert-manager CertificateRequest checker
+type CertRequestConfig struct {
+	Common              `yaml:",inline"`
+	Thresholds          `yaml:",inline"`
+	Kubeconfig          string   `yaml:"kubeconfig"`
+	LabelSelectors      []string `yaml:"label-selectors"`
+	AnnotationSelectors []string `yaml:"annotation-selectors"`
+	LabelsToMetric      []string `yaml:"labels-to-metric"`
+	Namespaces          []string `yaml:"namespaces"`
+	ExcludeNamespaces   []string `yaml:"exclude-namespaces"`
+}
+
+// CertificateConfig configures a cert-manager Certificate checker
+type CertificateConfig struct {
+	Common              `yaml:",inline"`
+	Kubeconfig          string   `yaml:"kubeconfig"`
+	LabelSelectors      []string `yaml:"label-selectors"`
+	AnnotationSelectors []string `yaml:"annotation-selectors"`
+	Namespaces          []string `yaml:"namespaces"`
+}
+
+// AwsConfig configures an AWS Secrets Manager checker
+type AwsConfig struct {
+	Common          `yaml:",inline"`
+	PasswordSources `yaml:",inline"`
+	Account         string   `yaml:"account"`
+	Region          string   `yaml:"region"`
+	KeySubString    string   `yaml:"key-substring"`
+	Secrets         []string `yaml:"secrets"`
+}
+
+// TLSEndpointConfig configures a TLS endpoint checker.  Targets use the same format as --tls-endpoint.
+type TLSEndpointConfig struct {
+	Common  `yaml:",inline"`
+	Targets []string      `yaml:"targets"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// IngressConfig configures an ingress TLS checker
+type IngressConfig struct {
+	Common              `yaml:",inline"`
+	Kubeconfig          string        `yaml:"kubeconfig"`
+	LabelSelectors      []string      `yaml:"label-selectors"`
+	AnnotationSelectors []string      `yaml:"annotation-selectors"`
+	Namespaces          []string      `yaml:"namespaces"`
+	ControllerService   string        `yaml:"controller-service"`
+	ProbeTimeout        time.Duration `yaml:"probe-timeout"`
+}
+
+// Load reads and parses the config file at path.  Unknown fields are rejected so typos do not silently disable a
+// checker.  The result is neither defaulted nor validated, see Merge and Validate.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse parses a YAML or JSON config
+func Parse(data []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Merge adds the checkers of other to c.  Global settings of other are only used if c does not set them, so settings
+// given on the command line win over the config file.
+func (c *Config) Merge(other *Config) {
+	if c.PollingPeriod == 0 {
+		c.PollingPeriod = other.PollingPeriod
+	}
+	if c.Kubeconfig == "" {
+		c.Kubeconfig = other.Kubeconfig
+	}
+	if c.ListPageSize == 0 {
+		c.ListPageSize = other.ListPageSize
+	}
+	if c.ScanConcurrency == 0 {
+		c.ScanConcurrency = other.ScanConcurrency
+	}
+	c.applyThresholdDefaults(other.Thresholds)
+
+	c.Certs = append(c.Certs, other.Certs...)
+	c.KubeConfigs = append(c.KubeConfigs, other.KubeConfigs...)
+	c.Secrets = append(c.Secrets, other.Secrets...)
+	c.ConfigMaps = append(c.ConfigMaps, other.ConfigMaps...)
+	c.Webhooks = append(c.Webhooks, other.Webhooks...)
+	c.CertRequests = append(c.CertRequests, other.CertRequests...)
+	c.Certificates = append(c.Certificates, other.Certificates...)
+	c.Aws = append(c.Aws, other.Aws...)
+	c.TLSEndpoints = append(c.TLSEndpoints, other.TLSEndpoints...)
+	c.Ingresses = append(c.Ingresses, other.Ingresses...)
+}
+
+// ApplyDefaults fills in every setting that was left empty
+func (c *Config) ApplyDefaults() {
+	if c.PollingPeriod == 0 {
+		c.PollingPeriod = DefaultPollingPeriod
+	}
+	if c.ListPageSize == 0 {
+		c.ListPageSize = DefaultListPageSize
+	}
+	if c.ScanConcurrency == 0 {
+		c.ScanConcurrency = DefaultScanConcurrency
+	}
+	c.applyThresholdDefaults(Thresholds{WarnBefore: DefaultWarnBefore, CriticalBefore: DefaultCriticalBefore})
+
+	for i := range c.Certs {
+		c.Certs[i].applyDefaults("certs", i, c.PollingPeriod)
+	}
+	for i := range c.KubeConfigs {
+		c.KubeConfigs[i].applyDefaults("kubeconfigs", i, c.PollingPeriod)
+	}
+	for i := range c.Secrets {
+		s := &c.Secrets[i]
+		s.applyDefaults("secrets", i, c.PollingPeriod)
+		s.Kubeconfig = defaultString(s.Kubeconfig, c.Kubeconfig)
+		s.applyThresholdDefaults(c.Thresholds)
+		if s.ListPageSize == 0 {
+			s.ListPageSize = c.ListPageSize
+		}
+		if s.ScanConcurrency == 0 {
+			s.ScanConcurrency = c.ScanConcurrency
+		}
+		s.Namespaces = defaultNamespaces(s.Namespaces)
+		if len(s.IncludeGlobs) == 0 {
+			s.IncludeGlobs = []string{"*"}
+		}
+		s.PasswordKey = defaultString(s.PasswordKey, DefaultPasswordKey)
+	}
+	for i := range c.ConfigMaps {
+		s := &c.ConfigMaps[i]
+		s.applyDefaults("configmaps", i, c.PollingPeriod)
+		s.Kubeconfig = defaultString(s.Kubeconfig, c.Kubeconfig)
+		s.applyThresholdDefaults(c.Thresholds)
+		if s.ListPageSize == 0 {
+			s.ListPageSize = c.ListPageSize
+		}
+		if s.ScanConcurrency == 0 {
+			s.ScanConcurrency = c.ScanConcurrency
+		}
+		s.Namespaces = defaultNamespaces(s.Namespaces)
+		if len(s.IncludeGlobs) == 0 {
+			s.IncludeGlobs = []string{"*"}
+		}
+	}
+	for i := range c.Webhooks {
+		s := &c.Webhooks[i]
+		s.applyDefaults("webhooks", i, c.PollingPeriod)
+		s.Kubeconfig = defaultString(s.Kubeconfig, c.Kubeconfig)
+	}
+	for i := range c.CertRequests {
+		s := &c.CertRequests[i]
+		s.applyDefaults("certrequests", i, c.PollingPeriod)
+		s.Kubeconfig = defaultString(s.Kubeconfig, c.Kubeconfig)
+		s.applyThresholdDefaults(c.Thresholds)
+		s.Namespaces = defaultNamespaces(s.Namespaces)
+	}
+	for i := range c.Certificates {
+		s := &c.Certificates[i]
+		s.applyDefaults("certificates", i, c.PollingPeriod)
+		s.Kubeconfig = defaultString(s.Kubeconfig, c.Kubeconfig)
+		s.Namespaces = defaultNamespaces(s.Namespaces)
+	}
+	for i := range c.Aws {
+		s := &c.Aws[i]
+		s.applyDefaults("aws", i, c.PollingPeriod)
+		s.KeySubString = defaultString(s.KeySubString, DefaultAwsKeySubString)
+	}
+	for i := range c.TLSEndpoints {
+		s := &c.TLSEndpoints[i]
+		s.applyDefaults("tls-endpoints", i, c.PollingPeriod)
+		if s.Timeout == 0 {
+			s.Timeout = DefaultTLSEndpointTimeout
+		}
+	}
+	for i := range c.Ingresses {
+		s := &c.Ingresses[i]
+		s.applyDefaults("ingresses", i, c.PollingPeriod)
+		s.Kubeconfig = defaultString(s.Kubeconfig, c.Kubeconfig)
+		s.Namespaces = defaultNamespaces(s.Namespaces)
+		if s.ProbeTimeout == 0 {
+			s.ProbeTimeout = DefaultTLSEndpointTimeout
+		}
+	}
+}
+
+// Validate checks every checker and returns all problems found, each prefixed with the name of the checker
+func (c *Config) Validate() error {
+	var errs []error
+	names := map[string]bool{}
+
+	check := func(common Common, checkerErrs ...error) {
+		if names[common.Name] {
+			checkerErrs = append(checkerErrs, fmt.Errorf("duplicate name"))
+		}
+		names[common.Name] = true
+
+		if common.PollingPeriod < 0 {
+			checkerErrs = append(checkerErrs, fmt.Errorf("polling-period must not be negative"))
+		}
+
+		for _, err := range checkerErrs {
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", common.Name, err))
+			}
+		}
+	}
+
+	if c.PollingPeriod < 0 {
+		errs = append(errs, fmt.Errorf("polling-period must not be negative"))
+	}
+	if c.ListPageSize < 0 {
+		errs = append(errs, fmt.Errorf("list-page-size must not be negative"))
+	}
+	if err := c.Thresholds.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.ScanConcurrency < 0 {
+		errs = append(errs, fmt.Errorf("scan-concurrency must not be negative"))
+	}
+
+	for _, s := range c.Certs {
+		check(s.Common, requireNotEmpty("include-globs", s.IncludeGlobs), validateGlobs(s.IncludeGlobs), validateGlobs(s.ExcludeGlobs))
+	}
+	for _, s := range c.KubeConfigs {
+		check(s.Common, requireNotEmpty("include-globs", s.IncludeGlobs), validateGlobs(s.IncludeGlobs), validateGlobs(s.ExcludeGlobs))
+	}
+	for _, s := range c.Secrets {
+		check(s.Common, validateLabelSelectors(s.LabelSelectors), checkers.ValidateAnnotationSelectors(s.AnnotationSelectors), checkers.ValidateLabelsToMetric(s.LabelsToMetric), validateLabelSelectors(s.NamespaceLabelSelectors), validateGlobs(s.Namespaces), validateGlobs(s.ExcludeNamespaces), validateGlobs(s.IncludeGlobs), validateGlobs(s.ExcludeGlobs), validateListPageSize(s.ListPageSize), validateScanConcurrency(s.ScanConcurrency), s.Thresholds.validate(), checkers.ValidateChainRoots(s.ChainRootsConfigMap))
+	}
+	for _, s := range c.ConfigMaps {
+		check(s.Common, validateLabelSelectors(s.LabelSelectors), checkers.ValidateAnnotationSelectors(s.AnnotationSelectors), checkers.ValidateLabelsToMetric(s.LabelsToMetric), validateLabelSelectors(s.NamespaceLabelSelectors), validateGlobs(s.Namespaces), validateGlobs(s.ExcludeNamespaces), validateGlobs(s.IncludeGlobs), validateGlobs(s.ExcludeGlobs), validateListPageSize(s.ListPageSize), validateScanConcurrency(s.ScanConcurrency), s.Thresholds.validate())
+	}
+	for _, s := range c.Webhooks {
+		check(s.Common, validateLabelSelectors(s.LabelSelectors), checkers.ValidateAnnotationSelectors(s.AnnotationSelectors), checkers.ValidateLabelsToMetric(s.LabelsToMetric))
+	}
+	for _, s := range c.CertRequests {
+		check(s.Common, validateLabelSelectors(s.LabelSelectors), checkers.ValidateAnnotationSelectors(s.AnnotationSelectors), checkers.ValidateLabelsToMetric(s.LabelsToMetric), validateGlobs(s.Namespaces), validateGlobs(s.ExcludeNamespaces), s.Thresholds.validate())
+	}
+	for _, s := range c.Certificates {
+		check(s.Common, validateLabelSelectors(s.LabelSelectors), checkers.ValidateAnnotationSelectors(s.AnnotationSelectors))
+	}
+	for _, s := range c.Aws {
+		var awsErrs []error
+		if s.Account == "" {
+			awsErrs = append(awsErrs, fmt.Errorf("account is required"))
+		}
+		if s.Region == "" {
+			awsErrs = append(awsErrs, fmt.Errorf("region is required"))
+		}
+		awsErrs = append(awsErrs, requireNotEmpty("secrets", s.Secrets))
+		check(s.Common, awsErrs...)
+	}
+	for _, s := range c.TLSEndpoints {
+		endpointErrs := []error{requireNotEmpty("targets", s.Targets)}
+		for _, target := range s.Targets {
+			_, err := checkers.ParseEndpointTarget(target, s.Timeout)
+			endpointErrs = append(endpointErrs, err)
+		}
+		check(s.Common, endpointErrs...)
+	}
+	for _, s := range c.Ingresses {
+		var serviceErr error
+		if s.ControllerService != "" {
+			_, serviceErr = checkers.ParseIngressControllerService(s.ControllerService)
+		}
+		check(s.Common, validateLabelSelectors(s.LabelSelectors), checkers.ValidateAnnotationSelectors(s.AnnotationSelectors), serviceErr)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Common) applyDefaults(section string, index int, pollingPeriod time.Duration) {
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("%s[%d]", section, index)
+	}
+	if c.PollingPeriod == 0 {
+		c.PollingPeriod = pollingPeriod
+	}
+}
+
+// applyThresholdDefaults fills in the thresholds left empty from defaults.  A default is capped by the threshold that
+// was set, so setting only warn-before below the default critical-before lowers critical-before to it, and setting only
+// critical-before above the default warn-before raises warn-before to it.
+func (t *Thresholds) applyThresholdDefaults(defaults Thresholds) {
+	warnSet, criticalSet := t.WarnBefore != 0, t.CriticalBefore != 0
+	if !warnSet {
+		t.WarnBefore = defaults.WarnBefore
+	}
+	if !criticalSet {
+		t.CriticalBefore = defaults.CriticalBefore
+	}
+	if warnSet && !criticalSet && t.CriticalBefore > t.WarnBefore {
+		t.CriticalBefore = t.WarnBefore
+	}
+	if criticalSet && !warnSet && t.WarnBefore != 0 && t.WarnBefore < t.CriticalBefore {
+		t.WarnBefore = t.CriticalBefore
+	}
+}
+
+func defaultString(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// defaultNamespaces returns a list that lists all namespaces if none were given
+func defaultNamespaces(namespaces []string) []string {
+	if len(namespaces) == 0 {
+		return []string{""}
+	}
+	return namespaces
+}
+
+func requireNotEmpty(field string, values []string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+	return nil
+}
+
+func validateGlobs(globs []string) error {
+	for _, glob := range globs {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+func validateLabelSelectors(selectors []string) error {
+	for _, selector := range selectors {
+		if _, err := labels.Parse(selector); err != nil {
+			return fmt.Errorf("invalid label selector %q: %w", selector, err)
+		}
+	}
+	return nil
+}
+
+func validateListPageSize(pageSize int64) error {
+	if pageSize < 0 {
+		return fmt.Errorf("list-page-size must not be negative")
+	}
+	return nil
+}
+
+func (t Thresholds) validate() error {
+	if t.WarnBefore < 0 || t.CriticalBefore < 0 {
+		return fmt.Errorf("warn-before and critical-before must not be negative")
+	}
+	if t.WarnBefore > 0 && t.CriticalBefore > t.WarnBefore {
+		return fmt.Errorf("critical-before %v must not be greater than warn-before %v", t.CriticalBefore, t.WarnBefore)
+	}
+	return nil
+}
+
+func validateScanConcurrency(concurrency int) error {
+	if concurrency < 0 {
+		return fmt.Errorf("scan-concurrency must not be negative")
+	}
+	return nil
+}
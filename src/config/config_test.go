@@ -0,0 +1,278 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+polling-period: 30m
+kubeconfig: /etc/kubeconfig
+warn-before: 240h
+secrets:
+  - name: team-a
+    label-selectors: ["team=a"]
+    namespaces: [team-a]
+  - name: team-b
+    polling-period: 5m
+    annotation-selectors: [cert-manager.io/certificate-name]
+    include-globs: ["*.crt"]
+    watch: true
+    list-page-size: 100
+    scan-concurrency: 8
+    critical-before: 24h
+    password-key: keystore-password
+    check-keypair: true
+tls-endpoints:
+  - targets: ["example.com:443,sni=example.com"]
+`
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	c.ApplyDefaults()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if len(c.Secrets) != 2 {
+		t.Fatalf("Expected 2 secret checkers, got %d", len(c.Secrets))
+	}
+
+	teamA, teamB := c.Secrets[0], c.Secrets[1]
+	if teamA.PollingPeriod != 30*time.Minute {
+		t.Errorf("Expected team-a to inherit the global polling period, got %v", teamA.PollingPeriod)
+	}
+	if teamB.PollingPeriod != 5*time.Minute {
+		t.Errorf("Expected team-b polling period 5m, got %v", teamB.PollingPeriod)
+	}
+	if teamA.Kubeconfig != "/etc/kubeconfig" {
+		t.Errorf("Expected team-a to inherit the global kubeconfig, got %q", teamA.Kubeconfig)
+	}
+	if len(teamA.IncludeGlobs) != 1 || teamA.IncludeGlobs[0] != "*" {
+		t.Errorf("Expected default include glob *, got %v", teamA.IncludeGlobs)
+	}
+	if len(teamB.Namespaces) != 1 || teamB.Namespaces[0] != "" {
+		t.Errorf("Expected team-b to search all namespaces, got %v", teamB.Namespaces)
+	}
+	if !teamB.Watch {
+		t.Error("Expected team-b to watch secrets")
+	}
+	if teamA.ListPageSize != DefaultListPageSize || teamB.ListPageSize != 100 {
+		t.Errorf("Expected list page sizes %d and 100, got %d and %d", DefaultListPageSize, teamA.ListPageSize, teamB.ListPageSize)
+	}
+	if teamA.ScanConcurrency != DefaultScanConcurrency || teamB.ScanConcurrency != 8 {
+		t.Errorf("Expected scan concurrencies %d and 8, got %d and %d", DefaultScanConcurrency, teamA.ScanConcurrency, teamB.ScanConcurrency)
+	}
+	if teamA.WarnBefore != 240*time.Hour || teamA.CriticalBefore != DefaultCriticalBefore {
+		t.Errorf("Expected team-a to inherit the global warn-before and the default critical-before, got %v and %v", teamA.WarnBefore, teamA.CriticalBefore)
+	}
+	if teamA.PasswordKey != DefaultPasswordKey || teamB.PasswordKey != "keystore-password" {
+		t.Errorf("Expected password keys %s and keystore-password, got %q and %q", DefaultPasswordKey, teamA.PasswordKey, teamB.PasswordKey)
+	}
+	if teamA.CheckKeypair || !teamB.CheckKeypair {
+		t.Errorf("Expected only team-b to check keypairs, got %v and %v", teamA.CheckKeypair, teamB.CheckKeypair)
+	}
+	if teamB.WarnBefore != 240*time.Hour || teamB.CriticalBefore != 24*time.Hour {
+		t.Errorf("Expected team-b thresholds 240h and 24h, got %v and %v", teamB.WarnBefore, teamB.CriticalBefore)
+	}
+
+	if len(c.TLSEndpoints) != 1 {
+		t.Fatalf("Expected 1 endpoint checker, got %d", len(c.TLSEndpoints))
+	}
+	if c.TLSEndpoints[0].Name != "tls-endpoints[0]" {
+		t.Errorf("Expected default name tls-endpoints[0], got %q", c.TLSEndpoints[0].Name)
+	}
+	if c.TLSEndpoints[0].Timeout != DefaultTLSEndpointTimeout {
+		t.Errorf("Expected default timeout, got %v", c.TLSEndpoints[0].Timeout)
+	}
+}
+
+func TestApplyDefaults_WarnOnly(t *testing.T) {
+	c, err := Parse([]byte("warn-before: 72h\nsecrets:\n  - name: global\n  - name: own\n    warn-before: 48h\n  - name: critical\n    critical-before: 1000h\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	c.ApplyDefaults()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	for i, want := range []Thresholds{
+		{WarnBefore: 72 * time.Hour, CriticalBefore: 72 * time.Hour},
+		{WarnBefore: 48 * time.Hour, CriticalBefore: 48 * time.Hour},
+		{WarnBefore: 1000 * time.Hour, CriticalBefore: 1000 * time.Hour},
+	} {
+		if got := c.Secrets[i].Thresholds; got != want {
+			t.Errorf("Expected %s thresholds %v, got %v", c.Secrets[i].Name, want, got)
+		}
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	c, err := Parse([]byte(`{"polling-period": "10m", "webhooks": [{"label-selectors": ["app=webhook"]}]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if c.PollingPeriod != 10*time.Minute {
+		t.Errorf("Expected polling period 10m, got %v", c.PollingPeriod)
+	}
+	if len(c.Webhooks) != 1 || c.Webhooks[0].LabelSelectors[0] != "app=webhook" {
+		t.Errorf("Expected one webhook checker, got %+v", c.Webhooks)
+	}
+}
+
+func TestParse_UnknownField(t *testing.T) {
+	_, err := Parse([]byte("secrets:\n  - label-selector: team=a\n"))
+	if err == nil {
+		t.Fatal("Expected an error for an unknown field")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr []string
+	}{
+		{
+			name:   "valid",
+			config: "certs:\n  - include-globs: [/etc/ssl/*.crt]\n",
+		},
+		{
+			name:    "missing include globs",
+			config:  "certs:\n  - name: node\n",
+			wantErr: []string{"node: include-globs must not be empty"},
+		},
+		{
+			name:    "invalid label selector",
+			config:  "secrets:\n  - label-selectors: [\"team in (a\"]\n",
+			wantErr: []string{"secrets[0]: invalid label selector"},
+		},
+		{
+			name:    "invalid glob",
+			config:  "configmaps:\n  - include-globs: [\"[\"]\n",
+			wantErr: []string{"configmaps[0]: invalid glob"},
+		},
+		{
+			name:    "duplicate names",
+			config:  "certrequests:\n  - name: cr\n  - name: cr\n",
+			wantErr: []string{"cr: duplicate name"},
+		},
+		{
+			name:    "incomplete aws",
+			config:  "aws:\n  - account: \"123\"\n",
+			wantErr: []string{"aws[0]: region is required", "aws[0]: secrets must not be empty"},
+		},
+		{
+			name:    "invalid endpoint and ingress controller service",
+			config:  "tls-endpoints:\n  - targets: [example.com]\ningresses:\n  - controller-service: ingress-nginx\n",
+			wantErr: []string{"tls-endpoints[0]: invalid endpoint", "ingresses[0]: invalid ingress controller service"},
+		},
+		{
+			name:    "negative polling period",
+			config:  "webhooks:\n  - polling-period: -1m\n",
+			wantErr: []string{"webhooks[0]: polling-period must not be negative"},
+		},
+		{
+			name:    "negative list page size",
+			config:  "configmaps:\n  - list-page-size: -1\n",
+			wantErr: []string{"configmaps[0]: list-page-size must not be negative"},
+		},
+		{
+			name:    "negative threshold",
+			config:  "certrequests:\n  - critical-before: -1h\n",
+			wantErr: []string{"certrequests[0]: warn-before and critical-before must not be negative"},
+		},
+		{
+			name:    "critical before warn",
+			config:  "secrets:\n  - warn-before: 24h\n    critical-before: 48h\n",
+			wantErr: []string{"secrets[0]: critical-before 48h0m0s must not be greater than warn-before 24h0m0s"},
+		},
+		{
+			name:    "conflicting labels to metric",
+			config:  "secrets:\n  - labels-to-metric: [app.kubernetes.io/name, app_kubernetes_io_name]\n",
+			wantErr: []string{"secrets[0]: label-to-metric entries"},
+		},
+		{
+			name:    "invalid annotation selector",
+			config:  "webhooks:\n  - annotation-selectors: [\"cert-exporter.io/scrape in (true\"]\n",
+			wantErr: []string{"webhooks[0]: invalid annotation selector"},
+		},
+		{
+			name:    "invalid namespace pattern",
+			config:  "certrequests:\n  - exclude-namespaces: [\"team-[\"]\n",
+			wantErr: []string{"certrequests[0]: invalid glob"},
+		},
+		{
+			name:    "negative scan concurrency",
+			config:  "secrets:\n  - scan-concurrency: -1\n",
+			wantErr: []string{"secrets[0]: scan-concurrency must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte(tt.config))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			c.ApplyDefaults()
+
+			err = c.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() expected errors %v", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	flags := &Config{
+		Kubeconfig: "/flag/kubeconfig",
+		Secrets:    []SecretConfig{{LabelSelectors: []string{"from=flags"}}},
+	}
+	file := &Config{
+		PollingPeriod: 15 * time.Minute,
+		Kubeconfig:    "/file/kubeconfig",
+		Secrets:       []SecretConfig{{LabelSelectors: []string{"from=file"}}},
+	}
+
+	flags.Merge(file)
+	flags.ApplyDefaults()
+
+	if flags.Kubeconfig != "/flag/kubeconfig" {
+		t.Errorf("Expected the kubeconfig flag to win, got %q", flags.Kubeconfig)
+	}
+	if flags.PollingPeriod != 15*time.Minute {
+		t.Errorf("Expected the polling period from the file, got %v", flags.PollingPeriod)
+	}
+	if len(flags.Secrets) != 2 {
+		t.Fatalf("Expected secret checkers from both flags and file, got %d", len(flags.Secrets))
+	}
+	if flags.Secrets[0].Name == flags.Secrets[1].Name {
+		t.Errorf("Expected distinct default names, got %q twice", flags.Secrets[0].Name)
+	}
+}
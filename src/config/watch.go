@@ -0,0 +1,44 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+)
+
+// WatchFile calls onChange whenever the content of the file at path changes.  The file is polled every interval, which
+// also notices the kubelet swapping the symlinks of a mounted ConfigMap.  It returns once ctx is cancelled.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	last := fileHash(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := fileHash(path)
+		if current == nil || bytes.Equal(current, last) {
+			continue
+		}
+		last = current
+		onChange()
+	}
+}
+
+// fileHash returns the hash of the file content or nil if it cannot be read
+func fileHash(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
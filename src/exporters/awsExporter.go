@@ -2,26 +2,40 @@ package exporters
 
 import (
 	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // AwsExporter exports AWS PEM file certs
 type AwsExporter struct {
+	scanBuffer
+
+	// PasswordSources are read on every export for the passwords of PKCS12 bundles and Java keystores
+	PasswordSources PasswordSources
 }
 
 // ExportMetrics exports the provided PEM file
 func (c *AwsExporter) ExportMetrics(file, secretName, key string) error {
-	metricCollection, err := secondsToExpiryFromCertAsBase64String(file)
+	passwords := c.PasswordSources.Passwords("")
+	metricCollection, err := secondsToExpiryFromCertAsBase64String(file, passwords)
 	if err != nil {
+		c.passwordFailed(err, passwords, sourceAws, "", secretName, key)
 		return err
 	}
 
+	c.certsParsed(len(metricCollection))
 	for _, metric := range metricCollection {
-		metrics.AwsCertExpirySeconds.WithLabelValues(secretName, key, file, metric.issuer, metric.cn).Set(metric.durationUntilExpiry)
+		c.set(metrics.AwsCertExpirySeconds, metric.durationUntilExpiry, secretName, key, file, metric.issuer, metric.cn)
+		c.set(metrics.AwsCertValidityRemainingRatio, metric.validityRemainingRatio, secretName, key, file, metric.issuer, metric.cn)
+		c.set(metrics.AwsCertInfo, 1, append([]string{secretName, key}, metric.infoLabelValues()...)...)
 	}
 
 	return nil
 }
 
 func (c *AwsExporter) ResetMetrics() {
+	c.reset()
 	metrics.AwsCertExpirySeconds.Reset()
+	metrics.AwsCertValidityRemainingRatio.Reset()
+	metrics.AwsCertInfo.Reset()
+	metrics.PasswordFailed.DeletePartialMatch(prometheus.Labels{"source": sourceAws})
 }
\ No newline at end of file
@@ -1,31 +1,47 @@
 package exporters
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/joe-elliott/cert-exporter/src/metrics"
 )
 
 // CertExporter exports PEM file certs
 type CertExporter struct {
+	scanBuffer
+
+	// PasswordSources are read on every export for the passwords of PKCS12 bundles and Java keystores, so rotated
+	// passwords are picked up
+	PasswordSources PasswordSources
 }
 
 // ExportMetrics exports the provided PEM file
 func (c *CertExporter) ExportMetrics(file, nodeName string) error {
-	metricCollection, err := secondsToExpiryFromCertAsFile(file)
+	passwords := c.PasswordSources.Passwords(file)
+	metricCollection, err := secondsToExpiryFromCertAsFile(file, passwords)
 	if err != nil {
+		c.passwordFailed(err, passwords, sourceCerts, "", file, "")
 		return err
 	}
 
+	c.certsParsed(len(metricCollection))
 	for _, metric := range metricCollection {
-		metrics.CertExpirySeconds.WithLabelValues(file, metric.issuer, metric.cn, nodeName).Set(metric.durationUntilExpiry)
-		metrics.CertNotAfterTimestamp.WithLabelValues(file, metric.issuer, metric.cn, nodeName).Set(metric.notAfter)
-		metrics.CertNotBeforeTimestamp.WithLabelValues(file, metric.issuer, metric.cn, nodeName).Set(metric.notBefore)
+		c.set(metrics.CertExpirySeconds, metric.durationUntilExpiry, file, metric.issuer, metric.cn, nodeName)
+		c.set(metrics.CertValidityRemainingRatio, metric.validityRemainingRatio, file, metric.issuer, metric.cn, nodeName)
+		c.set(metrics.CertNotAfterTimestamp, metric.notAfter, file, metric.issuer, metric.cn, nodeName)
+		c.set(metrics.CertNotBeforeTimestamp, metric.notBefore, file, metric.issuer, metric.cn, nodeName)
+		c.set(metrics.CertInfo, 1, append([]string{file, nodeName}, metric.infoLabelValues()...)...)
 	}
 
 	return nil
 }
 
 func (c *CertExporter) ResetMetrics() {
+	c.reset()
 	metrics.CertExpirySeconds.Reset()
+	metrics.CertValidityRemainingRatio.Reset()
 	metrics.CertNotAfterTimestamp.Reset()
 	metrics.CertNotBeforeTimestamp.Reset()
+	metrics.CertInfo.Reset()
+	metrics.PasswordFailed.DeletePartialMatch(prometheus.Labels{"source": sourceCerts})
 }
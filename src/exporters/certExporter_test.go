@@ -287,6 +287,59 @@ func TestCertExporter_PKCS12(t *testing.T) {
 	}
 }
 
+func TestCertExporter_Keystore(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	tmpDir := testutil.CreateTempCertDir(t)
+	certFile := tmpDir + "/truststore.jks"
+	passwordFile := tmpDir + "/password"
+
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root-ca", Days: 365, IsCA: true})
+	keystore := testutil.CreateKeystore(t, false, "changeit", testutil.KeystoreEntry{Alias: "my-ca", Certs: []*testutil.CertBundle{root}})
+	if err := os.WriteFile(certFile, keystore, 0644); err != nil {
+		t.Fatalf("Failed to write keystore: %v", err)
+	}
+	if err := os.WriteFile(passwordFile, []byte("wrong\n"), 0600); err != nil {
+		t.Fatalf("Failed to write password file: %v", err)
+	}
+
+	exporter := &CertExporter{PasswordSources: PasswordSources{File: "password"}}
+	exporter.ResetMetrics()
+
+	if err := exporter.ExportMetrics(certFile, "test-node"); err == nil {
+		t.Error("Expected an error for a wrong keystore password")
+	}
+
+	if err := os.WriteFile(passwordFile, []byte("changeit\n"), 0600); err != nil {
+		t.Fatalf("Failed to write password file: %v", err)
+	}
+	if err := exporter.ExportMetrics(certFile, "test-node"); err != nil {
+		t.Fatalf("ExportMetrics() failed for keystore: %v", err)
+	}
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	foundInfo := false
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_cert_info" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			if labels["cn"] == "root-ca" && labels["alias"] == "my-ca" && labels["filename"] == certFile {
+				foundInfo = true
+			}
+		}
+	}
+	if !foundInfo {
+		t.Error("Expected cert_info with the keystore alias")
+	}
+}
+
 func TestCertExporter_ResetMetrics(t *testing.T) {
 	// Create a custom registry for this test to avoid collisions
 	testRegistry := prometheus.NewRegistry()
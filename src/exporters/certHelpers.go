@@ -1,11 +1,19 @@
 package exporters
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -14,43 +22,92 @@ import (
 )
 
 type certMetric struct {
-	durationUntilExpiry float64
-	notAfter, notBefore float64
-	issuer              string
-	cn                  string
+	durationUntilExpiry    float64
+	validityRemainingRatio float64
+	notAfter, notBefore    float64
+	issuer                 string
+	cn                     string
+	fingerprint            string
+	serialNumber           string
+	dnsNames               []string
+	ipAddresses            []string
+	publicKeyAlgorithm     string
+	publicKeySize          int
+	signatureAlgorithm     string
+	isCA                   bool
+	// alias is the alias of the Java keystore entry holding the cert
+	alias string
 }
 
-func secondsToExpiryFromCertAsFile(file string) ([]certMetric, error) {
+// infoLabelValues returns the values for the certificate detail labels of the *_cert_info metrics
+// in the order they are declared by the metrics package.
+func (m certMetric) infoLabelValues() []string {
+	return []string{
+		m.issuer,
+		m.cn,
+		m.fingerprint,
+		m.serialNumber,
+		strings.Join(m.dnsNames, ","),
+		strings.Join(m.ipAddresses, ","),
+		m.publicKeyAlgorithm,
+		strconv.Itoa(m.publicKeySize),
+		m.signatureAlgorithm,
+		strconv.FormatBool(m.isCA),
+		m.alias,
+	}
+}
+
+func secondsToExpiryFromCertAsFile(file string, passwords Passwords) ([]certMetric, error) {
 	certBytes, err := os.ReadFile(file)
 	if err != nil {
 		return []certMetric{}, err
 	}
 
-	return secondsToExpiryFromCertAsBytes(certBytes, "")
+	return secondsToExpiryFromCertAsBytes(certBytes, passwords)
 }
 
-func secondsToExpiryFromCertAsBase64String(s string) ([]certMetric, error) {
+func secondsToExpiryFromCertAsBase64String(s string, passwords Passwords) ([]certMetric, error) {
 	certBytes, err := base64.StdEncoding.DecodeString(s)
 	if err != nil {
 		return []certMetric{}, err
 	}
 
-	return secondsToExpiryFromCertAsBytes(certBytes, "")
+	return secondsToExpiryFromCertAsBytes(certBytes, passwords)
 }
 
-func secondsToExpiryFromCertAsBytes(certBytes []byte, certPassword string) ([]certMetric, error) {
+// secondsToExpiryFromCertAsBytes parses the certs of a PEM file, Java keystore, DER cert, PKCS7 or PKCS12 bundle.
+// passwords are the candidates for the password of keystores and PKCS12 bundles.
+func secondsToExpiryFromCertAsBytes(certBytes []byte, passwords Passwords) ([]certMetric, error) {
 	var metrics []certMetric
 
 	parsed, metrics, err := parseAsPEM(certBytes)
 	if parsed {
 		return metrics, err
 	}
-	// Parse as PKCS ?
-	parsed, metrics, err = parseAsPKCS(certBytes, certPassword)
+	// Parse as a Java keystore ?
+	parsed, metrics, err = parseAsJKS(certBytes, passwords)
+	if parsed {
+		return metrics, err
+	}
+	// Parse as DER ?
+	parsed, metrics, _ = parseAsDER(certBytes)
 	if parsed {
 		return metrics, nil
 	}
-	return nil, fmt.Errorf("failed to parse as pem and pkcs12: %w", err)
+	// Parse as PKCS ?
+	parsed, metrics, err = parseAsPKCS(certBytes, passwords.Candidates)
+	if parsed {
+		return metrics, err
+	}
+	return nil, fmt.Errorf("failed to parse as pem, jks, der, pkcs7 and pkcs12: %w", err)
+}
+
+func secondsToExpiryFromCertificates(certs []*x509.Certificate) []certMetric {
+	metrics := make([]certMetric, 0, len(certs))
+	for _, cert := range certs {
+		metrics = append(metrics, getCertificateMetrics(cert))
+	}
+	return metrics
 }
 
 func getCertificateMetrics(cert *x509.Certificate) certMetric {
@@ -58,14 +115,64 @@ func getCertificateMetrics(cert *x509.Certificate) certMetric {
 	metric.notAfter = float64(cert.NotAfter.Unix())
 	metric.notBefore = float64(cert.NotBefore.Unix())
 	metric.durationUntilExpiry = time.Until(cert.NotAfter).Seconds()
+	metric.validityRemainingRatio = validityRemainingRatio(cert.NotBefore, cert.NotAfter, time.Now())
 	metric.issuer = cert.Issuer.CommonName
 	metric.cn = cert.Subject.CommonName
+	fingerprint := sha256.Sum256(cert.Raw)
+	metric.fingerprint = hex.EncodeToString(fingerprint[:])
+	if cert.SerialNumber != nil {
+		metric.serialNumber = cert.SerialNumber.Text(16)
+	}
+	metric.dnsNames = cert.DNSNames
+	for _, ip := range cert.IPAddresses {
+		metric.ipAddresses = append(metric.ipAddresses, ip.String())
+	}
+	metric.publicKeyAlgorithm = cert.PublicKeyAlgorithm.String()
+	metric.publicKeySize = publicKeySize(cert.PublicKey)
+	metric.signatureAlgorithm = cert.SignatureAlgorithm.String()
+	metric.isCA = cert.IsCA
 	return metric
 }
 
-func parseAsPKCS(certBytes []byte, certPassword string) (bool, []certMetric, error) {
+// validityRemainingRatio returns the fraction of the validity period between notBefore and notAfter that is left at
+// now, 1 before the period starts and 0 once it ended.
+func validityRemainingRatio(notBefore, notAfter, now time.Time) float64 {
+	lifetime := notAfter.Sub(notBefore)
+	if lifetime <= 0 {
+		return 0
+	}
+	return min(max(notAfter.Sub(now).Seconds()/lifetime.Seconds(), 0), 1)
+}
+
+// publicKeySize returns the size in bits of a certificate public key or 0 if the key type is unknown.
+func publicKeySize(publicKey any) int {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	default:
+		return 0
+	}
+}
+
+// parseAsPKCS parses a PKCS12 bundle, trying each password and then no password at all
+func parseAsPKCS(certBytes []byte, passwords []string) (bool, []certMetric, error) {
 	var metrics []certMetric
-	_, cert, caCerts, err := pkcs12.DecodeChain(certBytes, certPassword)
+	var cert *x509.Certificate
+	var caCerts []*x509.Certificate
+	err := pkcs12.ErrIncorrectPassword
+	for _, password := range append(slices.Clone(passwords), "") {
+		_, cert, caCerts, err = pkcs12.DecodeChain(certBytes, password)
+		if !errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			break
+		}
+	}
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return true, nil, fmt.Errorf("pkcs12: %w", errIncorrectPassword)
+	}
 	if err != nil {
 		return false, nil, err
 	}
@@ -88,7 +195,7 @@ func parseAsPEM(certBytes []byte) (bool, []certMetric, error) {
 	}
 	// Remove trailing whitespaces to prevent possible error in loop
 	rest = []byte(strings.TrimRightFunc(string(rest), unicode.IsSpace))
-	if block.Type == "CERTIFICATE" {
+	if block.Type == "CERTIFICATE" || block.Type == pemTypePKCS7 {
 		blocks = append(blocks, block)
 	}
 	// Export the remaining certificates in the certificate chain
@@ -97,11 +204,19 @@ func parseAsPEM(certBytes []byte) (bool, []certMetric, error) {
 		if block == nil {
 			return true, metrics, fmt.Errorf("Failed to parse intermediate as a pem")
 		}
-		if block.Type == "CERTIFICATE" {
+		if block.Type == "CERTIFICATE" || block.Type == pemTypePKCS7 {
 			blocks = append(blocks, block)
 		}
 	}
 	for _, block := range blocks {
+		if block.Type == pemTypePKCS7 {
+			certs, err := parsePKCS7(block.Bytes)
+			if err != nil {
+				return true, metrics, err
+			}
+			metrics = append(metrics, secondsToExpiryFromCertificates(certs)...)
+			continue
+		}
 		cert, err := x509.ParseCertificate(block.Bytes)
 		if err != nil {
 			return true, metrics, err
@@ -111,3 +226,34 @@ func parseAsPEM(certBytes []byte) (bool, []certMetric, error) {
 	}
 	return true, metrics, nil
 }
+
+// parseAsDER parses raw DER encoded certificates, as in .cer and .der files, or a DER encoded PKCS#7 bundle
+func parseAsDER(certBytes []byte) (bool, []certMetric, error) {
+	certs, err := x509.ParseCertificates(certBytes)
+	if err == nil && len(certs) > 0 {
+		return true, secondsToExpiryFromCertificates(certs), nil
+	}
+	certs, err = parsePKCS7(certBytes)
+	if err != nil {
+		return false, nil, err
+	}
+	return true, secondsToExpiryFromCertificates(certs), nil
+}
+
+// parseAsJKS parses a JKS or JCEKS keystore, exporting every cert with the alias of its entry
+func parseAsJKS(certBytes []byte, passwords Passwords) (bool, []certMetric, error) {
+	if !isJKS(certBytes) {
+		return false, nil, fmt.Errorf("Failed to parse as a jks")
+	}
+	certs, err := parseJKS(certBytes, passwords)
+	if err != nil {
+		return true, nil, err
+	}
+	metrics := make([]certMetric, 0, len(certs))
+	for _, c := range certs {
+		metric := getCertificateMetrics(c.cert)
+		metric.alias = c.alias
+		metrics = append(metrics, metric)
+	}
+	return true, metrics, nil
+}
@@ -1,8 +1,17 @@
 package exporters
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/pem"
+	"math"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/joe-elliott/cert-exporter/internal/testutil"
 )
@@ -191,3 +200,128 @@ func TestParseAsPEM(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCertificateMetrics_Info(t *testing.T) {
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		DNSNames:    []string{"example.com", "www.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+		Days:        30,
+		IsCA:        true,
+	})
+
+	metric := getCertificateMetrics(cert.Cert)
+
+	fingerprint := sha256.Sum256(cert.Cert.Raw)
+	if metric.fingerprint != hex.EncodeToString(fingerprint[:]) {
+		t.Errorf("Expected fingerprint %x, got %s", fingerprint, metric.fingerprint)
+	}
+	if metric.serialNumber != cert.Cert.SerialNumber.Text(16) {
+		t.Errorf("Expected serial number %s, got %s", cert.Cert.SerialNumber.Text(16), metric.serialNumber)
+	}
+	if metric.publicKeyAlgorithm != "RSA" || metric.publicKeySize != 2048 {
+		t.Errorf("Expected RSA 2048 public key, got %s %d", metric.publicKeyAlgorithm, metric.publicKeySize)
+	}
+	if metric.signatureAlgorithm != "SHA256-RSA" {
+		t.Errorf("Expected SHA256-RSA signature algorithm, got %s", metric.signatureAlgorithm)
+	}
+	if !metric.isCA {
+		t.Error("Expected cert to be reported as a CA")
+	}
+
+	values := metric.infoLabelValues()
+	want := []string{"", "", metric.fingerprint, metric.serialNumber, "example.com,www.example.com", "10.0.0.1", "RSA", "2048", "SHA256-RSA", "true", ""}
+	if len(values) != len(want) {
+		t.Fatalf("Expected %d info label values, got %d", len(want), len(values))
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("Expected info label value %d to be %q, got %q", i, want[i], values[i])
+		}
+	}
+}
+
+func TestPublicKeySize(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	if size := publicKeySize(&ecKey.PublicKey); size != 384 {
+		t.Errorf("Expected ECDSA P-384 key size 384, got %d", size)
+	}
+	if size := publicKeySize(edKey); size != 256 {
+		t.Errorf("Expected Ed25519 key size 256, got %d", size)
+	}
+	if size := publicKeySize("unknown"); size != 0 {
+		t.Errorf("Expected unknown key size 0, got %d", size)
+	}
+}
+
+func TestValidityRemainingRatio(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := notBefore.Add(100 * time.Hour)
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		now       time.Time
+		want      float64
+	}{
+		{"not yet valid", notBefore, notBefore.Add(-time.Hour), 1},
+		{"issued", notBefore, notBefore, 1},
+		{"80% used", notBefore, notBefore.Add(80 * time.Hour), 0.2},
+		{"expired", notBefore, notAfter.Add(time.Hour), 0},
+		{"no lifetime", notAfter, notAfter.Add(-time.Hour), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validityRemainingRatio(tt.notBefore, notAfter, tt.now); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("validityRemainingRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecondsToExpiryFromCertAsBytes_Formats(t *testing.T) {
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root", Days: 365, IsCA: true})
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "leaf", Days: 90}, root)
+	p7b := testutil.CreatePKCS7Bundle(t, leaf, root)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantCNs []string
+		wantErr bool
+	}{
+		{"der", leaf.Cert.Raw, []string{"leaf"}, false},
+		{"der chain", append(append([]byte{}, leaf.Cert.Raw...), root.Cert.Raw...), []string{"leaf", "root"}, false},
+		{"der pkcs7", p7b, []string{"leaf", "root"}, false},
+		{"pem pkcs7", pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: p7b}), []string{"leaf", "root"}, false},
+		{"pem pkcs7 and certificate", append(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: p7b}), leaf.CertPEM...), []string{"leaf", "root", "leaf"}, false},
+		{"corrupted pem pkcs7", pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: []byte("invalid")}), nil, true},
+		{"pkcs12", testutil.CreatePKCS12Bundle(t, leaf, []*testutil.CertBundle{root}, ""), []string{"leaf", "root"}, false},
+		{"garbage", []byte{0x30, 0x03, 0x02, 0x01, 0x01}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := secondsToExpiryFromCertAsBytes(tt.data, Passwords{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("secondsToExpiryFromCertAsBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(metrics) != len(tt.wantCNs) {
+				t.Fatalf("secondsToExpiryFromCertAsBytes() returned %d metrics, want %d", len(metrics), len(tt.wantCNs))
+			}
+			for i, cn := range tt.wantCNs {
+				if metrics[i].cn != cn {
+					t.Errorf("Expected cert %d CN %q, got %q", i, cn, metrics[i].cn)
+				}
+			}
+		})
+	}
+}
@@ -1,31 +1,49 @@
 package exporters
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/joe-elliott/cert-exporter/src/metrics"
 )
 
 // CertRequestExporter exports PEM file certs
 type CertRequestExporter struct {
+	scanBuffer
 }
 
 // ExportMetrics exports the provided PEM file
-func (c *CertRequestExporter) ExportMetrics(bytes []byte, certrequest, certrequestNamespace string) error {
-	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, "")
+func (c *CertRequestExporter) ExportMetrics(bytes []byte, certrequest, certrequestNamespace string, thresholds ExpiryThresholds) error {
+	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, Passwords{})
 	if err != nil {
 		return err
 	}
 
+	c.certsParsed(len(metricCollection))
 	for _, metric := range metricCollection {
-		metrics.CertRequestExpirySeconds.WithLabelValues(metric.issuer, metric.cn, certrequest, certrequestNamespace).Set(metric.durationUntilExpiry)
-		metrics.CertRequestNotAfterTimestamp.WithLabelValues(metric.issuer, metric.cn, certrequest, certrequestNamespace).Set(metric.notAfter)
-		metrics.CertRequestNotBeforeTimestamp.WithLabelValues(metric.issuer, metric.cn, certrequest, certrequestNamespace).Set(metric.notBefore)
+		c.set(metrics.CertRequestExpirySeconds, metric.durationUntilExpiry, metric.issuer, metric.cn, certrequest, certrequestNamespace)
+		c.set(metrics.CertRequestValidityRemainingRatio, metric.validityRemainingRatio, metric.issuer, metric.cn, certrequest, certrequestNamespace)
+		c.set(metrics.CertRequestNotAfterTimestamp, metric.notAfter, metric.issuer, metric.cn, certrequest, certrequestNamespace)
+		c.set(metrics.CertRequestNotBeforeTimestamp, metric.notBefore, metric.issuer, metric.cn, certrequest, certrequestNamespace)
+		c.set(metrics.CertRequestCertInfo, 1, append([]string{certrequest, certrequestNamespace}, metric.infoLabelValues()...)...)
+		c.setStatus(metric, thresholds, sourceCertRequests, certrequestNamespace, certrequest, "")
 	}
 
 	return nil
 }
 
+// ExportLabels exports the labels and annotations copied from a certrequest.  names are the prometheus labels values are
+// copied to.
+func (c *CertRequestExporter) ExportLabels(names, values []string, certrequest, certrequestNamespace string) {
+	c.set(metrics.CertRequestLabels.With(names), 1, append([]string{certrequest, certrequestNamespace}, values...)...)
+}
+
 func (c *CertRequestExporter) ResetMetrics() {
+	c.reset()
 	metrics.CertRequestExpirySeconds.Reset()
+	metrics.CertRequestValidityRemainingRatio.Reset()
 	metrics.CertRequestNotAfterTimestamp.Reset()
 	metrics.CertRequestNotBeforeTimestamp.Reset()
+	metrics.CertRequestCertInfo.Reset()
+	metrics.CertRequestLabels.Reset()
+	metrics.CertStatus.DeletePartialMatch(prometheus.Labels{"source": sourceCertRequests})
 }
@@ -27,7 +27,7 @@ func TestCertRequestExporter_ExportMetrics(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export metrics
-	err := exporter.ExportMetrics(cert.CertPEM, "test-certrequest", "test-namespace")
+	err := exporter.ExportMetrics(cert.CertPEM, "test-certrequest", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
@@ -113,7 +113,7 @@ func TestCertRequestExporter_ExportMetrics_Bundle(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export bundle metrics
-	err := exporter.ExportMetrics(bundle, "bundle-certrequest", "test-namespace")
+	err := exporter.ExportMetrics(bundle, "bundle-certrequest", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export bundle metrics: %v", err)
 	}
@@ -168,12 +168,12 @@ func TestCertRequestExporter_ExportMetrics_MultipleNamespaces(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export metrics for different namespaces
-	err := exporter.ExportMetrics(cert1.CertPEM, "certrequest-1", "namespace-1")
+	err := exporter.ExportMetrics(cert1.CertPEM, "certrequest-1", "namespace-1", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export cert1 metrics: %v", err)
 	}
 
-	err = exporter.ExportMetrics(cert2.CertPEM, "certrequest-2", "namespace-2")
+	err = exporter.ExportMetrics(cert2.CertPEM, "certrequest-2", "namespace-2", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export cert2 metrics: %v", err)
 	}
@@ -217,7 +217,7 @@ func TestCertRequestExporter_ExportMetrics_InvalidCert(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Try to export invalid certificate data
-	err := exporter.ExportMetrics([]byte("not a valid certificate"), "invalid-certrequest", "test-namespace")
+	err := exporter.ExportMetrics([]byte("not a valid certificate"), "invalid-certrequest", "test-namespace", ExpiryThresholds{})
 	if err == nil {
 		t.Error("Expected error when exporting invalid certificate data")
 	}
@@ -235,7 +235,7 @@ func TestCertRequestExporter_ResetMetrics(t *testing.T) {
 	exporter := &CertRequestExporter{}
 	exporter.ResetMetrics()
 
-	err := exporter.ExportMetrics(cert.CertPEM, "reset-certrequest", "test-namespace")
+	err := exporter.ExportMetrics(cert.CertPEM, "reset-certrequest", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
@@ -306,7 +306,7 @@ func TestCertRequestExporter_LabelValues(t *testing.T) {
 	certRequestName := "test-certrequest"
 	namespace := "test-ns"
 
-	err := exporter.ExportMetrics(signedCert.CertPEM, certRequestName, namespace)
+	err := exporter.ExportMetrics(signedCert.CertPEM, certRequestName, namespace, ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
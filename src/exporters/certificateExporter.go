@@ -0,0 +1,74 @@
+package exporters
+
+import (
+	"time"
+
+	cmapiv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+// CertificateExporter exports the status of cert-manager Certificate resources
+type CertificateExporter struct {
+	scanBuffer
+}
+
+// ExportMetrics exports the expiry, renewal and readiness status of the provided Certificate.  It returns whether the
+// renewal of the certificate is overdue.
+func (c *CertificateExporter) ExportMetrics(certificate *cmapiv1.Certificate) bool {
+	name, namespace, secretName := certificate.Name, certificate.Namespace, certificate.Spec.SecretName
+	status := certificate.Status
+	now := time.Now()
+
+	if status.NotAfter != nil {
+		c.set(metrics.CertificateExpirySeconds, status.NotAfter.Sub(now).Seconds(), name, namespace, secretName)
+		c.set(metrics.CertificateNotAfterTimestamp, float64(status.NotAfter.Unix()), name, namespace, secretName)
+
+		if status.NotBefore != nil {
+			c.set(metrics.CertificateValidityRemainingRatio, validityRemainingRatio(status.NotBefore.Time, status.NotAfter.Time, now), name, namespace, secretName)
+		}
+	}
+
+	overdue := false
+	if status.RenewalTime != nil {
+		// cert-manager moves renewalTime forward once it issued a new cert, so a renewal time in the past means the
+		// renewal is stuck
+		overdue = now.After(status.RenewalTime.Time)
+		c.set(metrics.CertificateRenewalSeconds, status.RenewalTime.Sub(now).Seconds(), name, namespace, secretName)
+		c.set(metrics.CertificateRenewalTimestamp, float64(status.RenewalTime.Unix()), name, namespace, secretName)
+		c.set(metrics.CertificateRenewalOverdue, boolToFloat(overdue), name, namespace, secretName)
+	}
+
+	failedIssuanceAttempts := 0
+	if status.FailedIssuanceAttempts != nil {
+		failedIssuanceAttempts = *status.FailedIssuanceAttempts
+	}
+
+	c.set(metrics.CertificateReady, boolToFloat(certificateConditionTrue(certificate, cmapiv1.CertificateConditionReady)), name, namespace, secretName)
+	c.set(metrics.CertificateIssuing, boolToFloat(certificateConditionTrue(certificate, cmapiv1.CertificateConditionIssuing)), name, namespace, secretName)
+	c.set(metrics.CertificateFailedIssuanceAttempts, float64(failedIssuanceAttempts), name, namespace, secretName)
+	return overdue
+}
+
+func (c *CertificateExporter) ResetMetrics() {
+	c.reset()
+	metrics.CertificateExpirySeconds.Reset()
+	metrics.CertificateNotAfterTimestamp.Reset()
+	metrics.CertificateValidityRemainingRatio.Reset()
+	metrics.CertificateRenewalSeconds.Reset()
+	metrics.CertificateRenewalTimestamp.Reset()
+	metrics.CertificateRenewalOverdue.Reset()
+	metrics.CertificateReady.Reset()
+	metrics.CertificateIssuing.Reset()
+	metrics.CertificateFailedIssuanceAttempts.Reset()
+}
+
+func certificateConditionTrue(certificate *cmapiv1.Certificate, conditionType cmapiv1.CertificateConditionType) bool {
+	for _, condition := range certificate.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == cmmeta.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package exporters
+
+import (
+	"testing"
+	"time"
+
+	cmapiv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCertificateExporter_ExportMetrics_NoStatus(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	exporter := &CertificateExporter{}
+	exporter.ResetMetrics()
+
+	// A freshly created certificate has neither notAfter nor renewalTime yet.
+	overdue := exporter.ExportMetrics(&cmapiv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default"},
+		Spec:       cmapiv1.CertificateSpec{SecretName: "new-tls"},
+	})
+	if overdue {
+		t.Error("Expected a certificate without a renewal time not to be overdue")
+	}
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := map[string]float64{}
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			if labels["certificate"] == "new" && labels["secret_name"] == "new-tls" {
+				found[mf.GetName()] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+
+	for _, name := range []string{"cert_exporter_certificate_expires_in_seconds", "cert_exporter_certificate_renewal_overdue"} {
+		if _, ok := found[name]; ok {
+			t.Errorf("Expected no %s without a status", name)
+		}
+	}
+	if v, ok := found["cert_exporter_certificate_ready"]; !ok || v != 0 {
+		t.Errorf("Expected certificate_ready to be 0, got %v", found)
+	}
+	if v, ok := found["cert_exporter_certificate_failed_issuance_attempts"]; !ok || v != 0 {
+		t.Errorf("Expected certificate_failed_issuance_attempts to be 0, got %v", found)
+	}
+}
+
+func TestCertificateExporter_ExportMetrics_Overdue(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	exporter := &CertificateExporter{}
+	exporter.ResetMetrics()
+
+	certificate := func(name string, renewal time.Time) *cmapiv1.Certificate {
+		return &cmapiv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       cmapiv1.CertificateSpec{SecretName: name + "-tls"},
+			Status:     cmapiv1.CertificateStatus{RenewalTime: &metav1.Time{Time: renewal}},
+		}
+	}
+	if !exporter.ExportMetrics(certificate("stuck", time.Now().Add(-time.Hour))) {
+		t.Error("Expected a renewal time in the past to be overdue")
+	}
+	if exporter.ExportMetrics(certificate("pending", time.Now().Add(time.Hour))) {
+		t.Error("Expected a renewal time in the future not to be overdue")
+	}
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	found := map[string]float64{}
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_certificate_renewal_overdue" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			found[getLabelMap(metric)["certificate"]] = metric.GetGauge().GetValue()
+		}
+	}
+	if found["stuck"] != 1 || found["pending"] != 0 || len(found) != 2 {
+		t.Errorf("Expected certificate_renewal_overdue to match the returned state, got %v", found)
+	}
+}
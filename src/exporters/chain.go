@@ -0,0 +1,166 @@
+package exporters
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+// Reasons reported in the reason label of secret_chain_valid
+const (
+	// ChainReasonValid means the chain verifies against the roots and is presented in order
+	ChainReasonValid = "valid"
+	// ChainReasonUnknownAuthority means no path from the leaf to one of the roots was found, e.g. because an
+	// intermediate is missing
+	ChainReasonUnknownAuthority = "unknown_authority"
+	// ChainReasonExpiredLeaf means the leaf cert is expired or not yet valid
+	ChainReasonExpiredLeaf = "expired_leaf"
+	// ChainReasonExpiredIntermediate means an intermediate issuing the leaf is expired or not yet valid
+	ChainReasonExpiredIntermediate = "expired_intermediate"
+	// ChainReasonWrongOrder means the chain verifies but the certs are not a leaf followed by its issuers in order
+	ChainReasonWrongOrder = "wrong_order"
+	// ChainReasonInvalid means the chain failed to verify for another reason, e.g. an issuer that is not a CA
+	ChainReasonInvalid = "invalid"
+	// ChainReasonInvalidCert means the cert is missing or could not be parsed
+	ChainReasonInvalidCert = "invalid_cert"
+)
+
+// chainResult is the outcome of verifying a chain
+type chainResult struct {
+	reason string
+	// length is the number of certs from the leaf to the root on the verified path, 0 if none was found
+	length int
+	// notAfter is the earliest expiry on the verified path
+	notAfter time.Time
+}
+
+// verifyChain verifies the chain in certBytes, the leaf first, against roots at now.  It returns the error of certs
+// that could not be parsed.
+func verifyChain(certBytes []byte, roots *x509.CertPool, now time.Time) (chainResult, error) {
+	certs, err := parseCertificateChain(certBytes)
+	if err != nil {
+		return chainResult{reason: ChainReasonInvalidCert}, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return chainResult{reason: chainFailure(err, certs, now)}, nil
+	}
+
+	path := slices.MinFunc(chains, func(a, b []*x509.Certificate) int { return len(a) - len(b) })
+	result := chainResult{reason: ChainReasonValid, length: len(path), notAfter: path[0].NotAfter}
+	for _, cert := range path[1:] {
+		if cert.NotAfter.Before(result.notAfter) {
+			result.notAfter = cert.NotAfter
+		}
+	}
+	if !inOrder(certs) {
+		result.reason = ChainReasonWrongOrder
+	}
+	return result, nil
+}
+
+// chainFailure returns the reason a chain failed to verify with err
+func chainFailure(err error, certs []*x509.Certificate, now time.Time) string {
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) && invalid.Cert == certs[0] && invalid.Reason == x509.Expired {
+		return ChainReasonExpiredLeaf
+	}
+	// The verifier reports an expired intermediate as an unknown authority, so follow the issuers of the leaf
+	// through the presented certs
+	seen := map[*x509.Certificate]bool{certs[0]: true}
+	for cert := certs[0]; cert != nil; {
+		issuer := presentedIssuer(cert, certs, seen)
+		if issuer != nil && (now.After(issuer.NotAfter) || now.Before(issuer.NotBefore)) {
+			return ChainReasonExpiredIntermediate
+		}
+		cert = issuer
+	}
+	if errors.As(err, &x509.UnknownAuthorityError{}) {
+		return ChainReasonUnknownAuthority
+	}
+	return ChainReasonInvalid
+}
+
+// presentedIssuer returns the first of certs that signed cert and was not seen yet, marking it as seen
+func presentedIssuer(cert *x509.Certificate, certs []*x509.Certificate, seen map[*x509.Certificate]bool) *x509.Certificate {
+	for _, candidate := range certs {
+		if !seen[candidate] && cert.CheckSignatureFrom(candidate) == nil {
+			seen[candidate] = true
+			return candidate
+		}
+	}
+	return nil
+}
+
+// inOrder tells whether every cert is signed by the one following it
+func inOrder(certs []*x509.Certificate) bool {
+	for i := 0; i < len(certs)-1; i++ {
+		if certs[i].CheckSignatureFrom(certs[i+1]) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCertificateChain returns the certs of a PEM or DER encoded chain in the order they appear
+func parseCertificateChain(certBytes []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := certBytes
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+
+	certs, err := x509.ParseCertificates(certBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certs, nil
+}
+
+// ExportChain exports whether the chain of a kubernetes.io/tls secret verifies against roots, and the length and
+// earliest expiry of the verified path.  It returns the error of a cert that could not be parsed.
+func (c *SecretExporter) ExportChain(certBytes []byte, roots *x509.CertPool, keyName, secretName, secretNamespace string) error {
+	result, err := verifyChain(certBytes, roots, time.Now())
+	value := 0.0
+	if result.reason == ChainReasonValid {
+		value = 1
+	}
+	c.set(metrics.SecretChainValid, value, keyName, secretName, secretNamespace, result.reason)
+	if result.length > 0 {
+		c.set(metrics.SecretChainLength, float64(result.length), keyName, secretName, secretNamespace)
+		c.set(metrics.SecretChainExpirySeconds, time.Until(result.notAfter).Seconds(), keyName, secretName, secretNamespace)
+	}
+	return err
+}
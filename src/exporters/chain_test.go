@@ -0,0 +1,117 @@
+package exporters
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestVerifyChain(t *testing.T) {
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root", Days: 3650, IsCA: true})
+	otherRoot := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "other root", Days: 3650, IsCA: true})
+	intermediate := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "intermediate", Days: 365, IsCA: true}, root)
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "leaf", Days: 30}, intermediate)
+	expiredLeaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "expired leaf", Days: -1}, intermediate)
+	expiredIntermediate := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "expired intermediate", Days: -1, IsCA: true}, root)
+	orphan := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "orphan", Days: 30}, expiredIntermediate)
+	direct := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "direct", Days: 30}, root)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Cert)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(otherRoot.Cert)
+
+	tests := []struct {
+		name     string
+		certs    []byte
+		roots    *x509.CertPool
+		reason   string
+		length   int
+		notAfter time.Time
+	}{
+		{"valid", testutil.CreateCertBundle(leaf, intermediate), roots, ChainReasonValid, 3, leaf.Cert.NotAfter},
+		{"valid with root", testutil.CreateCertBundle(leaf, intermediate, root), roots, ChainReasonValid, 3, leaf.Cert.NotAfter},
+		{"issued by root", direct.Cert.Raw, roots, ChainReasonValid, 2, direct.Cert.NotAfter},
+		{"wrong order", testutil.CreateCertBundle(intermediate, leaf), roots, ChainReasonWrongOrder, 2, intermediate.Cert.NotAfter},
+		{"missing intermediate", leaf.CertPEM, roots, ChainReasonUnknownAuthority, 0, time.Time{}},
+		{"untrusted root", testutil.CreateCertBundle(leaf, intermediate), otherRoots, ChainReasonUnknownAuthority, 0, time.Time{}},
+		{"expired leaf", testutil.CreateCertBundle(expiredLeaf, intermediate), roots, ChainReasonExpiredLeaf, 0, time.Time{}},
+		{"expired intermediate", testutil.CreateCertBundle(orphan, expiredIntermediate), roots, ChainReasonExpiredIntermediate, 0, time.Time{}},
+		{"unrelated expired intermediate", testutil.CreateCertBundle(leaf, expiredIntermediate), roots, ChainReasonUnknownAuthority, 0, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := verifyChain(tt.certs, tt.roots, time.Now())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.reason != tt.reason || result.length != tt.length || !result.notAfter.Equal(tt.notAfter) {
+				t.Errorf("Expected %s with length %d expiring %v, got %s with length %d expiring %v", tt.reason, tt.length, tt.notAfter, result.reason, result.length, result.notAfter)
+			}
+		})
+	}
+
+	if result, err := verifyChain([]byte("not a cert"), roots, time.Now()); err == nil || result.reason != ChainReasonInvalidCert {
+		t.Errorf("Expected %s and an error for an invalid cert, got %s and %v", ChainReasonInvalidCert, result.reason, err)
+	}
+}
+
+func TestSecretExporter_ExportChain(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root", Days: 3650, IsCA: true})
+	intermediate := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "intermediate", Days: 365, IsCA: true}, root)
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "leaf", Days: 30}, intermediate)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Cert)
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+	if err := exporter.ExportChain(testutil.CreateCertBundle(leaf, intermediate), roots, "tls.crt", "complete", "default"); err != nil {
+		t.Errorf("Expected no error for a complete chain, got %v", err)
+	}
+	if err := exporter.ExportChain(leaf.CertPEM, roots, "tls.crt", "incomplete", "default"); err != nil {
+		t.Errorf("Expected no error for an incomplete chain, got %v", err)
+	}
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	valid := map[string]string{}
+	lengths := map[string]float64{}
+	expiries := map[string]float64{}
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			switch mf.GetName() {
+			case "cert_exporter_secret_chain_valid":
+				valid[labels["secret_name"]] = labels["reason"]
+				if want := labels["reason"] == ChainReasonValid; want != (metric.GetGauge().GetValue() == 1) {
+					t.Errorf("Expected secret_chain_valid of %s to be 1 only when valid, got %v", labels["secret_name"], metric.GetGauge().GetValue())
+				}
+			case "cert_exporter_secret_chain_length":
+				lengths[labels["secret_name"]] = metric.GetGauge().GetValue()
+			case "cert_exporter_secret_chain_expires_in_seconds":
+				expiries[labels["secret_name"]] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+
+	if valid["complete"] != ChainReasonValid || valid["incomplete"] != ChainReasonUnknownAuthority {
+		t.Errorf("Expected reasons %s and %s, got %v", ChainReasonValid, ChainReasonUnknownAuthority, valid)
+	}
+	if len(lengths) != 1 || lengths["complete"] != 3 {
+		t.Errorf("Expected a chain length of 3 for the complete chain only, got %v", lengths)
+	}
+	if expiry := expiries["complete"]; len(expiries) != 1 || expiry <= 29*24*3600 || expiry > 30*24*3600 {
+		t.Errorf("Expected the complete chain to expire with its leaf in 30 days, got %v", expiries)
+	}
+}
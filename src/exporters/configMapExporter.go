@@ -1,31 +1,49 @@
 package exporters
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/joe-elliott/cert-exporter/src/metrics"
 )
 
 // ConfigMapExporter exports PEM file certs
 type ConfigMapExporter struct {
+	scanBuffer
 }
 
 // ExportMetrics exports the provided PEM file
-func (c *ConfigMapExporter) ExportMetrics(bytes []byte, keyName, configMapName, configMapNamespace string) error {
-	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, "")
+func (c *ConfigMapExporter) ExportMetrics(bytes []byte, keyName, configMapName, configMapNamespace string, thresholds ExpiryThresholds) error {
+	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, Passwords{})
 	if err != nil {
 		return err
 	}
 
+	c.certsParsed(len(metricCollection))
 	for _, metric := range metricCollection {
-		metrics.ConfigMapExpirySeconds.WithLabelValues(keyName, metric.issuer, metric.cn, configMapName, configMapNamespace).Set(metric.durationUntilExpiry)
-		metrics.ConfigMapNotAfterTimestamp.WithLabelValues(keyName, metric.issuer, metric.cn, configMapName, configMapNamespace).Set(metric.notAfter)
-		metrics.ConfigMapNotBeforeTimestamp.WithLabelValues(keyName, metric.issuer, metric.cn, configMapName, configMapNamespace).Set(metric.notBefore)
+		c.set(metrics.ConfigMapExpirySeconds, metric.durationUntilExpiry, keyName, metric.issuer, metric.cn, configMapName, configMapNamespace)
+		c.set(metrics.ConfigMapValidityRemainingRatio, metric.validityRemainingRatio, keyName, metric.issuer, metric.cn, configMapName, configMapNamespace)
+		c.set(metrics.ConfigMapNotAfterTimestamp, metric.notAfter, keyName, metric.issuer, metric.cn, configMapName, configMapNamespace)
+		c.set(metrics.ConfigMapNotBeforeTimestamp, metric.notBefore, keyName, metric.issuer, metric.cn, configMapName, configMapNamespace)
+		c.set(metrics.ConfigMapCertInfo, 1, append([]string{keyName, configMapName, configMapNamespace}, metric.infoLabelValues()...)...)
+		c.setStatus(metric, thresholds, sourceConfigMaps, configMapNamespace, configMapName, keyName)
 	}
 
 	return nil
 }
 
+// ExportLabels exports the labels and annotations copied from a configmap.  names are the prometheus labels values are
+// copied to.
+func (c *ConfigMapExporter) ExportLabels(names, values []string, configMapName, configMapNamespace string) {
+	c.set(metrics.ConfigMapLabels.With(names), 1, append([]string{configMapName, configMapNamespace}, values...)...)
+}
+
 func (c *ConfigMapExporter) ResetMetrics() {
+	c.reset()
 	metrics.ConfigMapExpirySeconds.Reset()
+	metrics.ConfigMapValidityRemainingRatio.Reset()
 	metrics.ConfigMapNotAfterTimestamp.Reset()
 	metrics.ConfigMapNotBeforeTimestamp.Reset()
+	metrics.ConfigMapCertInfo.Reset()
+	metrics.ConfigMapLabels.Reset()
+	metrics.CertStatus.DeletePartialMatch(prometheus.Labels{"source": sourceConfigMaps})
 }
@@ -26,7 +26,7 @@ func TestConfigMapExporter_ExportMetrics(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export metrics
-	err := exporter.ExportMetrics(cert.CertPEM, "ca.crt", "test-configmap", "test-namespace")
+	err := exporter.ExportMetrics(cert.CertPEM, "ca.crt", "test-configmap", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
@@ -112,7 +112,7 @@ func TestConfigMapExporter_ExportMetrics_Bundle(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export bundle metrics
-	err := exporter.ExportMetrics(bundle, "ca-bundle.crt", "bundle-configmap", "test-namespace")
+	err := exporter.ExportMetrics(bundle, "ca-bundle.crt", "bundle-configmap", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export bundle metrics: %v", err)
 	}
@@ -167,12 +167,12 @@ func TestConfigMapExporter_ExportMetrics_MultipleKeys(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export metrics for multiple keys in same configmap
-	err := exporter.ExportMetrics(cert1.CertPEM, "cert1.crt", "multi-key-configmap", "test-namespace")
+	err := exporter.ExportMetrics(cert1.CertPEM, "cert1.crt", "multi-key-configmap", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export cert1 metrics: %v", err)
 	}
 
-	err = exporter.ExportMetrics(cert2.CertPEM, "cert2.crt", "multi-key-configmap", "test-namespace")
+	err = exporter.ExportMetrics(cert2.CertPEM, "cert2.crt", "multi-key-configmap", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export cert2 metrics: %v", err)
 	}
@@ -218,7 +218,7 @@ func TestConfigMapExporter_ExportMetrics_InvalidCert(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Try to export invalid certificate data
-	err := exporter.ExportMetrics([]byte("not a valid certificate"), "invalid.crt", "invalid-configmap", "test-namespace")
+	err := exporter.ExportMetrics([]byte("not a valid certificate"), "invalid.crt", "invalid-configmap", "test-namespace", ExpiryThresholds{})
 	if err == nil {
 		t.Error("Expected error when exporting invalid certificate data")
 	}
@@ -236,7 +236,7 @@ func TestConfigMapExporter_ResetMetrics(t *testing.T) {
 	exporter := &ConfigMapExporter{}
 	exporter.ResetMetrics()
 
-	err := exporter.ExportMetrics(cert.CertPEM, "ca.crt", "reset-configmap", "test-namespace")
+	err := exporter.ExportMetrics(cert.CertPEM, "ca.crt", "reset-configmap", "test-namespace", ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
@@ -299,7 +299,7 @@ func TestConfigMapExporter_LabelValues(t *testing.T) {
 	configMapName := "test-labels-configmap"
 	configMapNamespace := "test-labels-ns"
 
-	err := exporter.ExportMetrics(cert.CertPEM, keyName, configMapName, configMapNamespace)
+	err := exporter.ExportMetrics(cert.CertPEM, keyName, configMapName, configMapNamespace, ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
@@ -0,0 +1,47 @@
+package exporters
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+// EndpointExporter exports certs served by TLS endpoints
+type EndpointExporter struct {
+	scanBuffer
+}
+
+// ExportMetrics exports the certificate chain presented by the endpoint
+func (c *EndpointExporter) ExportMetrics(certs []*x509.Certificate, endpoint, serverName string) {
+	c.certsParsed(len(certs))
+	for _, metric := range secondsToExpiryFromCertificates(certs) {
+		c.set(metrics.EndpointExpirySeconds, metric.durationUntilExpiry, endpoint, serverName, metric.issuer, metric.cn)
+		c.set(metrics.EndpointValidityRemainingRatio, metric.validityRemainingRatio, endpoint, serverName, metric.issuer, metric.cn)
+		c.set(metrics.EndpointNotAfterTimestamp, metric.notAfter, endpoint, serverName, metric.issuer, metric.cn)
+		c.set(metrics.EndpointNotBeforeTimestamp, metric.notBefore, endpoint, serverName, metric.issuer, metric.cn)
+		c.set(metrics.EndpointCertInfo, 1, append([]string{endpoint, serverName}, metric.infoLabelValues()...)...)
+	}
+}
+
+// ExportProbe exports the outcome of a probe.  The handshake duration is only exported for successful probes.
+func (c *EndpointExporter) ExportProbe(endpoint, serverName string, success bool, handshakeDuration time.Duration) {
+	if !success {
+		c.set(metrics.EndpointProbeSuccess, 0, endpoint, serverName)
+		return
+	}
+
+	c.set(metrics.EndpointProbeSuccess, 1, endpoint, serverName)
+	c.set(metrics.EndpointHandshakeDurationSeconds, handshakeDuration.Seconds(), endpoint, serverName)
+}
+
+func (c *EndpointExporter) ResetMetrics() {
+	c.reset()
+	metrics.EndpointExpirySeconds.Reset()
+	metrics.EndpointValidityRemainingRatio.Reset()
+	metrics.EndpointNotAfterTimestamp.Reset()
+	metrics.EndpointNotBeforeTimestamp.Reset()
+	metrics.EndpointCertInfo.Reset()
+	metrics.EndpointProbeSuccess.Reset()
+	metrics.EndpointHandshakeDurationSeconds.Reset()
+}
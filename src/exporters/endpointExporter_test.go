@@ -0,0 +1,93 @@
+package exporters
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEndpointExporter_ExportMetrics(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	ca := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "endpoint-ca", Days: 365, IsCA: true})
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "endpoint-leaf", Days: 30}, ca)
+
+	exporter := &EndpointExporter{}
+	exporter.ResetMetrics()
+
+	exporter.ExportMetrics([]*x509.Certificate{leaf.Cert, ca.Cert}, "example.com:443", "example.com")
+	exporter.ExportProbe("example.com:443", "example.com", true, 50*time.Millisecond)
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	cns := map[string]bool{}
+	foundSuccess := false
+	foundDuration := false
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			if labels["endpoint"] != "example.com:443" || labels["server_name"] != "example.com" {
+				continue
+			}
+			switch mf.GetName() {
+			case "cert_exporter_endpoint_expires_in_seconds":
+				cns[labels["cn"]] = true
+			case "cert_exporter_endpoint_probe_success":
+				foundSuccess = metric.GetGauge().GetValue() == 1
+			case "cert_exporter_endpoint_handshake_duration_seconds":
+				foundDuration = metric.GetGauge().GetValue() == 0.05
+			}
+		}
+	}
+
+	if !cns["endpoint-leaf"] || !cns["endpoint-ca"] {
+		t.Errorf("Expected expiry metrics for the whole served chain, got %v", cns)
+	}
+	if !foundSuccess {
+		t.Error("Expected endpoint_probe_success to be 1")
+	}
+	if !foundDuration {
+		t.Error("Expected endpoint_handshake_duration_seconds to be 0.05")
+	}
+}
+
+func TestEndpointExporter_ExportProbe_Failure(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	exporter := &EndpointExporter{}
+	exporter.ResetMetrics()
+
+	exporter.ExportProbe("down.example.com:443", "", false, time.Second)
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	foundFailure := false
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "cert_exporter_endpoint_probe_success":
+			for _, metric := range mf.GetMetric() {
+				if getLabelMap(metric)["endpoint"] == "down.example.com:443" && metric.GetGauge().GetValue() == 0 {
+					foundFailure = true
+				}
+			}
+		case "cert_exporter_endpoint_handshake_duration_seconds":
+			t.Error("Expected no handshake duration for a failed probe")
+		}
+	}
+
+	if !foundFailure {
+		t.Error("Expected endpoint_probe_success to be 0")
+	}
+}
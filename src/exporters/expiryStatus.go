@@ -0,0 +1,59 @@
+package exporters
+
+import (
+	"time"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+// Severities reported in the severity label of cert_status
+const (
+	SeverityOK       = "ok"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+	SeverityExpired  = "expired"
+)
+
+var severities = []string{SeverityOK, SeverityWarning, SeverityCritical, SeverityExpired}
+
+// Sources reported in the source label of cert_status and password_failed
+const (
+	sourceSecrets      = "secrets"
+	sourceConfigMaps   = "configmaps"
+	sourceCertRequests = "certrequests"
+	sourceCerts        = "certs"
+	sourceAws          = "aws"
+)
+
+// ExpiryThresholds decide how severe the remaining validity of a cert is.  A cert expiring within CriticalBefore is
+// critical, one expiring within WarnBefore a warning.
+type ExpiryThresholds struct {
+	WarnBefore     time.Duration
+	CriticalBefore time.Duration
+}
+
+// severity returns the severity of a cert that expires in untilExpiry
+func (t ExpiryThresholds) severity(untilExpiry time.Duration) string {
+	switch {
+	case untilExpiry <= 0:
+		return SeverityExpired
+	case untilExpiry <= t.CriticalBefore:
+		return SeverityCritical
+	case untilExpiry <= t.WarnBefore:
+		return SeverityWarning
+	default:
+		return SeverityOK
+	}
+}
+
+// setStatus records the cert_status series of a cert, one per severity with the current one set to 1
+func (b *scanBuffer) setStatus(metric certMetric, thresholds ExpiryThresholds, source, namespace, name, keyName string) {
+	current := thresholds.severity(time.Duration(metric.durationUntilExpiry * float64(time.Second)))
+	for _, severity := range severities {
+		value := 0.0
+		if severity == current {
+			value = 1
+		}
+		b.set(metrics.CertStatus, value, source, namespace, name, keyName, metric.issuer, metric.cn, severity)
+	}
+}
@@ -0,0 +1,92 @@
+package exporters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestExpiryThresholds_Severity(t *testing.T) {
+	thresholds := ExpiryThresholds{WarnBefore: 30 * 24 * time.Hour, CriticalBefore: 7 * 24 * time.Hour}
+
+	tests := []struct {
+		untilExpiry time.Duration
+		want        string
+	}{
+		{365 * 24 * time.Hour, SeverityOK},
+		{30 * 24 * time.Hour, SeverityWarning},
+		{10 * 24 * time.Hour, SeverityWarning},
+		{24 * time.Hour, SeverityCritical},
+		{0, SeverityExpired},
+		{-time.Hour, SeverityExpired},
+	}
+
+	for _, tt := range tests {
+		if got := thresholds.severity(tt.untilExpiry); got != tt.want {
+			t.Errorf("severity(%v) = %q, want %q", tt.untilExpiry, got, tt.want)
+		}
+	}
+}
+
+func TestSecretExporter_CertStatus(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "status", Organization: "test-org", Country: "US", Province: "CA", Days: 10,
+	})
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+
+	thresholds := ExpiryThresholds{WarnBefore: 30 * 24 * time.Hour, CriticalBefore: 7 * 24 * time.Hour}
+	exporter.BeginSecret("status-secret", "test-namespace")
+	if err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", "status-secret", "test-namespace", Passwords{}, thresholds); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	exporter.EndSecret()
+
+	got := certStatus(t, testRegistry)
+	want := map[string]float64{SeverityOK: 0, SeverityWarning: 1, SeverityCritical: 0, SeverityExpired: 0}
+	if len(got) != len(want) {
+		t.Fatalf("Expected a series per severity, got %v", got)
+	}
+	for severity, value := range want {
+		if got[severity] != value {
+			t.Errorf("Expected severity %s to be %v, got %v", severity, value, got[severity])
+		}
+	}
+
+	exporter.DeleteMetrics("status-secret", "test-namespace")
+	if got := certStatus(t, testRegistry); len(got) != 0 {
+		t.Errorf("Expected DeleteMetrics to remove the cert_status series, got %v", got)
+	}
+}
+
+// certStatus returns the cert_exporter_cert_status values of the status-secret secret by severity
+func certStatus(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := map[string]float64{}
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_cert_status" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			if labels["source"] == "secrets" && labels["name"] == "status-secret" && labels["namespace"] == "test-namespace" && labels["key_name"] == "tls.crt" && labels["cn"] == "status" {
+				values[labels["severity"]] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return values
+}
@@ -4,4 +4,11 @@ package exporters
 type Exporter interface {
 	ExportMetrics(file, nodeName string) error
 	ResetMetrics()
+	BeginScan()
+	EndScan()
+	AbortScan()
+	ScanFailed() bool
+	Clear()
+	ObjectScanned()
+	ScanError(reason string)
 }
@@ -0,0 +1,90 @@
+package exporters
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+const (
+	// IngressSourceSecret labels certs read from the secret referenced by an ingress
+	IngressSourceSecret = "secret"
+	// IngressSourceServed labels certs served through the ingress controller
+	IngressSourceServed = "served"
+)
+
+// IngressExporter exports certs used by ingress TLS hosts
+type IngressExporter struct {
+	scanBuffer
+}
+
+// ExportSecretMetrics exports the certs in the secret referenced by an ingress TLS entry and returns the fingerprint of the leaf cert
+func (c *IngressExporter) ExportSecretMetrics(bytes []byte, ingressName, ingressNamespace, host, secretName string) (string, error) {
+	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, Passwords{})
+	if err != nil {
+		return "", err
+	}
+	if len(metricCollection) == 0 {
+		return "", fmt.Errorf("no certificate found in secret %s", secretName)
+	}
+
+	c.export(metricCollection, ingressName, ingressNamespace, host, secretName, IngressSourceSecret)
+	return metricCollection[0].fingerprint, nil
+}
+
+// ExportServedMetrics exports the chain served for an ingress host and returns the fingerprint of the leaf cert
+func (c *IngressExporter) ExportServedMetrics(certs []*x509.Certificate, ingressName, ingressNamespace, host, secretName string) string {
+	metricCollection := secondsToExpiryFromCertificates(certs)
+	if len(metricCollection) == 0 {
+		return ""
+	}
+
+	c.export(metricCollection, ingressName, ingressNamespace, host, secretName, IngressSourceServed)
+	return metricCollection[0].fingerprint
+}
+
+func (c *IngressExporter) export(metricCollection []certMetric, ingressName, ingressNamespace, host, secretName, source string) {
+	c.certsParsed(len(metricCollection))
+	for _, metric := range metricCollection {
+		c.set(metrics.IngressExpirySeconds, metric.durationUntilExpiry, ingressName, ingressNamespace, host, secretName, source, metric.issuer, metric.cn)
+		c.set(metrics.IngressValidityRemainingRatio, metric.validityRemainingRatio, ingressName, ingressNamespace, host, secretName, source, metric.issuer, metric.cn)
+		c.set(metrics.IngressNotAfterTimestamp, metric.notAfter, ingressName, ingressNamespace, host, secretName, source, metric.issuer, metric.cn)
+		c.set(metrics.IngressNotBeforeTimestamp, metric.notBefore, ingressName, ingressNamespace, host, secretName, source, metric.issuer, metric.cn)
+		c.set(metrics.IngressCertInfo, 1, append([]string{ingressName, ingressNamespace, host, secretName, source}, metric.infoLabelValues()...)...)
+	}
+}
+
+// ExportSecretMissing exports whether the secret referenced by an ingress TLS entry exists
+func (c *IngressExporter) ExportSecretMissing(ingressName, ingressNamespace, secretName string, missing bool) {
+	c.set(metrics.IngressTLSSecretMissing, boolToFloat(missing), ingressName, ingressNamespace, secretName)
+}
+
+// ExportFingerprintMismatch exports whether the cert served for an ingress host differs from the referenced secret
+func (c *IngressExporter) ExportFingerprintMismatch(ingressName, ingressNamespace, host, secretName string, mismatch bool) {
+	c.set(metrics.IngressTLSFingerprintMismatch, boolToFloat(mismatch), ingressName, ingressNamespace, host, secretName)
+}
+
+// ExportProbe exports whether probing an ingress host through the ingress controller succeeded
+func (c *IngressExporter) ExportProbe(ingressName, ingressNamespace, host string, success bool) {
+	c.set(metrics.IngressProbeSuccess, boolToFloat(success), ingressName, ingressNamespace, host)
+}
+
+func (c *IngressExporter) ResetMetrics() {
+	c.reset()
+	metrics.IngressExpirySeconds.Reset()
+	metrics.IngressValidityRemainingRatio.Reset()
+	metrics.IngressNotAfterTimestamp.Reset()
+	metrics.IngressNotBeforeTimestamp.Reset()
+	metrics.IngressCertInfo.Reset()
+	metrics.IngressTLSSecretMissing.Reset()
+	metrics.IngressTLSFingerprintMismatch.Reset()
+	metrics.IngressProbeSuccess.Reset()
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
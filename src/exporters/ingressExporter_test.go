@@ -0,0 +1,57 @@
+package exporters
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestIngressExporter_ExportMetrics(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	secretCert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "app.example.com", Days: 30})
+	servedCert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "app.example.com", Days: 10})
+
+	exporter := &IngressExporter{}
+	exporter.ResetMetrics()
+
+	secretFingerprint, err := exporter.ExportSecretMetrics(secretCert.CertPEM, "app", "default", "app.example.com", "app-tls")
+	if err != nil {
+		t.Fatalf("Failed to export secret metrics: %v", err)
+	}
+	servedFingerprint := exporter.ExportServedMetrics([]*x509.Certificate{servedCert.Cert}, "app", "default", "app.example.com", "app-tls")
+
+	if secretFingerprint == "" || servedFingerprint == "" || secretFingerprint == servedFingerprint {
+		t.Errorf("Expected two different fingerprints, got %q and %q", secretFingerprint, servedFingerprint)
+	}
+
+	if _, err := exporter.ExportSecretMetrics([]byte("not a cert"), "app", "default", "app.example.com", "app-tls"); err == nil {
+		t.Error("Expected an error for a secret without a certificate")
+	}
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	sources := map[string]bool{}
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_ingress_not_after_timestamp" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			if labels["ingress_name"] == "app" && labels["ingress_namespace"] == "default" && labels["host"] == "app.example.com" {
+				sources[labels["source"]] = true
+			}
+		}
+	}
+
+	if !sources[IngressSourceSecret] || !sources[IngressSourceServed] {
+		t.Errorf("Expected metrics for both sources, got %v", sources)
+	}
+}
@@ -0,0 +1,209 @@
+package exporters
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Magic numbers at the start of Java keystores
+const (
+	jksMagic   = 0xFEEDFEED
+	jceksMagic = 0xCECECECE
+)
+
+// Tags of the entries of a Java keystore
+const (
+	jksPrivateKeyEntry  = 1
+	jksTrustedCertEntry = 2
+	jksSecretKeyEntry   = 3
+)
+
+// jksDigestWhitener is mixed into the integrity digest of Java keystores by sun.security.provider.JavaKeyStore
+const jksDigestWhitener = "Mighty Aphrodite"
+
+// jksMaxResyncOffsets bounds the offsets tried in total to find the entries following secret key entries, so a
+// keystore crafted to make the search backtrack cannot hold up the checker
+const jksMaxResyncOffsets = 1 << 14
+
+// keystoreCert is a certificate found in a Java keystore along with the alias of the entry holding it
+type keystoreCert struct {
+	alias string
+	cert  *x509.Certificate
+}
+
+// isJKS tells whether data starts with the magic number of a JKS or JCEKS keystore
+func isJKS(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	magic := binary.BigEndian.Uint32(data)
+	return magic == jksMagic || magic == jceksMagic
+}
+
+// parseJKS returns the certificates of every trusted cert entry and of the chain of every private key entry of a JKS
+// or JCEKS keystore.  Secret key entries hold no certs and are skipped.  Certificates are stored in the clear, so the
+// passwords are only used to verify the integrity digest at the end of the keystore.  Private keys are never decrypted.
+func parseJKS(data []byte, passwords Passwords) ([]keystoreCert, error) {
+	if len(data) < sha1.Size {
+		return nil, errors.New("keystore is truncated")
+	}
+	body, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	if !jksDigestMatches(body, digest, passwords) {
+		return nil, fmt.Errorf("keystore was tampered with, or %w", errIncorrectPassword)
+	}
+
+	r := &jksReader{data: body}
+	r.uint32() // magic
+	version := r.uint32()
+	count := r.uint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported keystore version %d", version)
+	}
+	resyncBudget := jksMaxResyncOffsets
+	return parseJKSEntries(r.data, count, version, &resyncBudget)
+}
+
+// parseJKSEntries reads count entries, which have to take up all of data.  Secret key entries are serialized Java
+// objects whose length is not stored, so the entry following one is found by trying every offset from which the
+// remaining entries parse, until resyncBudget offsets were tried in total.
+func parseJKSEntries(data []byte, count, version uint32, resyncBudget *int) ([]keystoreCert, error) {
+	r := &jksReader{data: data}
+	var certs []keystoreCert
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		tag := r.uint32()
+		alias := r.utf()
+		r.bytes(8) // creation date
+
+		switch tag {
+		case jksPrivateKeyEntry:
+			r.bytes(int(r.uint32())) // encrypted private key
+			chainLength := r.uint32()
+			for j := uint32(0); j < chainLength && r.err == nil; j++ {
+				if cert := r.cert(version); cert != nil {
+					certs = append(certs, keystoreCert{alias: alias, cert: cert})
+				}
+			}
+		case jksTrustedCertEntry:
+			if cert := r.cert(version); cert != nil {
+				certs = append(certs, keystoreCert{alias: alias, cert: cert})
+			}
+		case jksSecretKeyEntry:
+			if r.err != nil {
+				break
+			}
+			if i == count-1 {
+				return certs, nil
+			}
+			for offset := 1; offset < len(r.data); offset++ {
+				if *resyncBudget == 0 {
+					return nil, fmt.Errorf("gave up looking for the entry following secret key entry %q", alias)
+				}
+				*resyncBudget--
+				if rest, err := parseJKSEntries(r.data[offset:], count-i-1, version, resyncBudget); err == nil {
+					return append(certs, rest...), nil
+				}
+			}
+			return nil, fmt.Errorf("keystore entry %q is a secret key entry not followed by a readable entry", alias)
+		default:
+			return nil, fmt.Errorf("keystore entry %q has unknown tag %d", alias, tag)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.data) > 0 {
+		return nil, errors.New("keystore has trailing data")
+	}
+	return certs, nil
+}
+
+// jksDigestMatches tells whether the integrity digest of a keystore matches one of the non-empty passwords.  A digest
+// that matches none of them is only a mismatch if one of the passwords is specific to the keystore; candidates
+// configured for every cert of a checker may well belong to other keystores, so the digest is unverifiable then.
+func jksDigestMatches(body, digest []byte, passwords Passwords) bool {
+	checked := false
+	for i, password := range passwords.Candidates {
+		if password == "" {
+			continue
+		}
+		if bytes.Equal(digest, jksDigest(body, password)) {
+			return true
+		}
+		checked = checked || i < passwords.Specific
+	}
+	return !checked
+}
+
+// jksDigest computes the integrity digest of a Java keystore from its password as UTF-16 and its contents
+func jksDigest(body []byte, password string) []byte {
+	h := sha1.New()
+	for _, c := range utf16.Encode([]rune(password)) {
+		h.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	h.Write([]byte(jksDigestWhitener))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// jksReader reads the big endian fields of a Java keystore, remembering the first error
+type jksReader struct {
+	data []byte
+	err  error
+}
+
+func (r *jksReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data) {
+		r.err = errors.New("keystore is truncated")
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *jksReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// utf reads a string written by java.io.DataOutput.writeUTF.  Its modified UTF-8 only differs from UTF-8 for NUL and
+// supplementary characters, which are not expected in aliases.
+func (r *jksReader) utf() string {
+	b := r.bytes(2)
+	if b == nil {
+		return ""
+	}
+	return string(r.bytes(int(binary.BigEndian.Uint16(b))))
+}
+
+// cert reads a certificate.  Keystores of version 2 prefix it with its type, and types other than X.509 are skipped.
+func (r *jksReader) cert(version uint32) *x509.Certificate {
+	certType := "X.509"
+	if version == 2 {
+		certType = r.utf()
+	}
+	der := r.bytes(int(r.uint32()))
+	if r.err != nil || certType != "X.509" {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	return cert
+}
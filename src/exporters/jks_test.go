@@ -0,0 +1,114 @@
+package exporters
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+)
+
+func TestParseJKS(t *testing.T) {
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root", Days: 365, IsCA: true})
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "leaf", Days: 90}, root)
+	entries := []testutil.KeystoreEntry{
+		{Alias: "server", Certs: []*testutil.CertBundle{leaf, root}, PrivateKey: true},
+		{Alias: "ca", Certs: []*testutil.CertBundle{root}},
+	}
+
+	for _, jceks := range []bool{false, true} {
+		keystore := testutil.CreateKeystore(t, jceks, "changeit", entries...)
+		if !isJKS(keystore) {
+			t.Fatalf("Expected keystore to be detected, jceks %v", jceks)
+		}
+
+		for _, candidates := range [][]string{{"changeit"}, {"wrong", "changeit"}, {""}, nil} {
+			passwords := Passwords{Candidates: candidates, Specific: len(candidates)}
+			certs, err := parseJKS(keystore, passwords)
+			if err != nil {
+				t.Fatalf("parseJKS() error = %v, jceks %v, passwords %q", err, jceks, candidates)
+			}
+			want := []struct{ alias, cn string }{{"server", "leaf"}, {"server", "root"}, {"ca", "root"}}
+			if len(certs) != len(want) {
+				t.Fatalf("Expected %d certs, got %d", len(want), len(certs))
+			}
+			for i, w := range want {
+				if certs[i].alias != w.alias || certs[i].cert.Subject.CommonName != w.cn {
+					t.Errorf("Expected cert %d to be %s in %s, got %s in %s", i, w.cn, w.alias, certs[i].cert.Subject.CommonName, certs[i].alias)
+				}
+			}
+		}
+
+		if _, err := parseJKS(keystore, Passwords{Candidates: []string{"wrong", ""}, Specific: 2}); !errors.Is(err, errIncorrectPassword) {
+			t.Errorf("Expected an incorrect password error for a wrong password, got %v, jceks %v", err, jceks)
+		}
+		if _, err := parseJKS(keystore[:len(keystore)/2], Passwords{}); err == nil {
+			t.Errorf("Expected an error for a truncated keystore, jceks %v", jceks)
+		}
+	}
+
+	if isJKS(leaf.CertPEM) || isJKS(leaf.Cert.Raw) {
+		t.Error("Expected certs not to be detected as keystores")
+	}
+}
+
+func TestParseJKS_UnverifiableDigest(t *testing.T) {
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "trusted", Days: 90})
+	keystore := testutil.CreateKeystore(t, false, "changeit", testutil.KeystoreEntry{Alias: "ca", Certs: []*testutil.CertBundle{cert}})
+
+	// Candidates configured for every cert of the checker may belong to other keystores
+	global := Passwords{Candidates: []string{"other-keystore"}}
+	if certs, err := parseJKS(keystore, global); err != nil || len(certs) != 1 {
+		t.Errorf("Expected the cert of a keystore only global passwords mismatch, got %d certs and %v", len(certs), err)
+	}
+
+	// A password of the keystore itself, e.g. from the password key of its secret, has to match
+	specific := Passwords{Candidates: []string{"wrong"}, Specific: 1}
+	specific.Add(global)
+	if _, err := parseJKS(keystore, specific); !errors.Is(err, errIncorrectPassword) {
+		t.Errorf("Expected an incorrect password error for a mismatched password of the keystore, got %v", err)
+	}
+}
+
+func TestParseJKS_SecretKeyEntries(t *testing.T) {
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root", Days: 365, IsCA: true})
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "leaf", Days: 90}, root)
+
+	for _, jceks := range []bool{false, true} {
+		keystore := testutil.CreateKeystore(t, jceks, "changeit",
+			testutil.KeystoreEntry{Alias: "aes", SecretKey: true},
+			testutil.KeystoreEntry{Alias: "server", Certs: []*testutil.CertBundle{leaf, root}, PrivateKey: true},
+			testutil.KeystoreEntry{Alias: "hmac", SecretKey: true},
+			testutil.KeystoreEntry{Alias: "ca", Certs: []*testutil.CertBundle{root}},
+			testutil.KeystoreEntry{Alias: "last", SecretKey: true},
+		)
+
+		certs, err := parseJKS(keystore, Passwords{Candidates: []string{"changeit"}, Specific: 1})
+		if err != nil {
+			t.Fatalf("parseJKS() error = %v, jceks %v", err, jceks)
+		}
+		var aliases []string
+		for _, c := range certs {
+			aliases = append(aliases, c.alias+"/"+c.cert.Subject.CommonName)
+		}
+		if want := []string{"server/leaf", "server/root", "ca/root"}; !slices.Equal(aliases, want) {
+			t.Errorf("Expected the certs %v around the secret key entries, got %v, jceks %v", want, aliases, jceks)
+		}
+	}
+}
+
+func TestParseJKS_SecretKeyResyncBounded(t *testing.T) {
+	// Back to back secret key entries without serialized keys parse from many offsets, and the count promises more
+	// entries than there are, so finding the entries following them backtracks through every combination
+	entry := []byte{0, 0, 0, jksSecretKeyEntry, 0, 1, 'k', 0, 0, 0, 0, 0, 0, 0, 0}
+	keystore := []byte{0xFE, 0xED, 0xFE, 0xED, 0, 0, 0, 2, 0, 0, 1, 0}
+	for range 200 {
+		keystore = append(keystore, entry...)
+	}
+	keystore = append(keystore, make([]byte, 20)...)
+
+	if _, err := parseJKS(keystore, Passwords{}); err == nil || !strings.Contains(err.Error(), "gave up") {
+		t.Errorf("Expected the search to give up once the resync budget is spent, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package exporters
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Reasons reported in the reason label of secret_keypair_match
+const (
+	// KeypairReasonMatch means the private key belongs to the cert
+	KeypairReasonMatch = "match"
+	// KeypairReasonMismatch means the private key does not belong to the cert
+	KeypairReasonMismatch = "mismatch"
+	// KeypairReasonEncryptedKey means the private key is encrypted and could not be compared
+	KeypairReasonEncryptedKey = "encrypted_key"
+	// KeypairReasonInvalidKey means the private key is missing or could not be parsed
+	KeypairReasonInvalidKey = "invalid_key"
+	// KeypairReasonInvalidCert means the cert is missing or could not be parsed
+	KeypairReasonInvalidCert = "invalid_cert"
+)
+
+// errEncryptedKey is returned for private keys encrypted with a passphrase
+var errEncryptedKey = errors.New("private key is encrypted")
+
+// keypairMatch tells whether the private key in keyBytes belongs to the first cert in certBytes, returning one of the
+// KeypairReason constants and the error that prevented the comparison, if any
+func keypairMatch(certBytes, keyBytes []byte) (string, error) {
+	certs, err := parseCertificateChain(certBytes)
+	if err != nil {
+		return KeypairReasonInvalidCert, err
+	}
+	cert := certs[0]
+	key, err := parsePrivateKey(keyBytes)
+	if errors.Is(err, errEncryptedKey) {
+		return KeypairReasonEncryptedKey, err
+	}
+	if err != nil {
+		return KeypairReasonInvalidKey, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return KeypairReasonInvalidKey, fmt.Errorf("unsupported private key type %T", key)
+	}
+	public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(cert.PublicKey) {
+		return KeypairReasonMismatch, nil
+	}
+	return KeypairReasonMatch, nil
+}
+
+// parsePrivateKey parses an RSA, ECDSA or Ed25519 private key in the PKCS#1, SEC1 or PKCS#8 format, PEM or DER
+// encoded
+func parsePrivateKey(keyBytes []byte) (any, error) {
+	rest := keyBytes
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "ENCRYPTED PRIVATE KEY":
+			return nil, errEncryptedKey
+		case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY":
+			// Legacy OpenSSL encryption keeps the block type and adds a Proc-Type header
+			if block.Headers["Proc-Type"] == "4,ENCRYPTED" {
+				return nil, errEncryptedKey
+			}
+			return parsePrivateKeyDER(block.Bytes)
+		}
+	}
+	if len(keyBytes) == 0 {
+		return nil, errors.New("no private key found")
+	}
+	return parsePrivateKeyDER(keyBytes)
+}
+
+// parsePrivateKeyDER tries the PKCS#8, PKCS#1 and SEC1 formats in turn
+func parsePrivateKeyDER(der []byte) (any, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("failed to parse private key as pkcs8, pkcs1 and sec1")
+}
@@ -0,0 +1,158 @@
+package exporters
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestKeypairMatch(t *testing.T) {
+	rsaCert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "rsa", Days: 30})
+	otherCert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "other", Days: 30})
+	intermediate := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "intermediate", Days: 30, IsCA: true})
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ecdsa key: %v", err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal ecdsa key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cert   []byte
+		key    []byte
+		reason string
+	}{
+		{"rsa pkcs1", rsaCert.CertPEM, rsaCert.PrivateKeyPEM, KeypairReasonMatch},
+		{"rsa pkcs8", rsaCert.CertPEM, pkcs8PEM(t, rsaCert.PrivateKey), KeypairReasonMatch},
+		{"rsa der", rsaCert.Cert.Raw, x509.MarshalPKCS1PrivateKey(rsaCert.PrivateKey), KeypairReasonMatch},
+		{"leaf first in chain", append(append([]byte{}, rsaCert.CertPEM...), intermediate.CertPEM...), rsaCert.PrivateKeyPEM, KeypairReasonMatch},
+		{"ecdsa sec1", selfSignedPEM(t, ecKey), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}), KeypairReasonMatch},
+		{"ecdsa pkcs8", selfSignedPEM(t, ecKey), pkcs8PEM(t, ecKey), KeypairReasonMatch},
+		{"ed25519 pkcs8", selfSignedPEM(t, edKey), pkcs8PEM(t, edKey), KeypairReasonMatch},
+		{"rotated cert", otherCert.CertPEM, rsaCert.PrivateKeyPEM, KeypairReasonMismatch},
+		{"different key type", selfSignedPEM(t, ecKey), rsaCert.PrivateKeyPEM, KeypairReasonMismatch},
+		{"pkcs8 encrypted", rsaCert.CertPEM, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte{0x30}}), KeypairReasonEncryptedKey},
+		{"legacy encrypted", rsaCert.CertPEM, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-256-CBC,00"}, Bytes: []byte{0x30}}), KeypairReasonEncryptedKey},
+		{"garbage key", rsaCert.CertPEM, []byte("not a key"), KeypairReasonInvalidKey},
+		{"missing key", rsaCert.CertPEM, nil, KeypairReasonInvalidKey},
+		{"garbage cert", []byte("not a cert"), rsaCert.PrivateKeyPEM, KeypairReasonInvalidCert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := keypairMatch(tt.cert, tt.key)
+			if reason != tt.reason {
+				t.Errorf("Expected reason %s, got %s (%v)", tt.reason, reason, err)
+			}
+			if failed := tt.reason != KeypairReasonMatch && tt.reason != KeypairReasonMismatch; failed != (err != nil) {
+				t.Errorf("Expected an error only when the keypair could not be compared, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSecretExporter_ExportKeypair(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "tls", Days: 30})
+	other := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "other", Days: 30})
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+	if err := exporter.ExportKeypair(cert.CertPEM, cert.PrivateKeyPEM, "good", "default"); err != nil {
+		t.Errorf("Expected no error for a matching keypair, got %v", err)
+	}
+	if err := exporter.ExportKeypair(other.CertPEM, cert.PrivateKeyPEM, "rotated", "default"); err != nil {
+		t.Errorf("Expected no error for a mismatched keypair, got %v", err)
+	}
+	encrypted := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte{0x30}})
+	if err := exporter.ExportKeypair(cert.CertPEM, encrypted, "encrypted", "default"); err != nil {
+		t.Errorf("Expected encrypted keys to be reported in the reason label only, got %v", err)
+	}
+	if err := exporter.ExportKeypair(cert.CertPEM, []byte("not a key"), "broken", "default"); err == nil {
+		t.Error("Expected an error for an unparsable key")
+	}
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	type result struct {
+		reason string
+		value  float64
+	}
+	got := map[string]result{}
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_secret_keypair_match" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			got[labels["secret_name"]] = result{labels["reason"], metric.GetGauge().GetValue()}
+		}
+	}
+	want := map[string]result{
+		"good":      {KeypairReasonMatch, 1},
+		"rotated":   {KeypairReasonMismatch, 0},
+		"encrypted": {KeypairReasonEncryptedKey, 0},
+		"broken":    {KeypairReasonInvalidKey, 0},
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected %d secret_keypair_match series, got %v", len(want), got)
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("Expected %s to be %v, got %v", name, w, got[name])
+		}
+	}
+}
+
+// pkcs8PEM encodes key as a PEM PKCS#8 private key
+func pkcs8PEM(t *testing.T, key any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal pkcs8 key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// selfSignedPEM creates a self-signed PEM cert for key
+func selfSignedPEM(t *testing.T, key crypto.Signer) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "keypair"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -10,6 +10,7 @@ import (
 
 // KubeConfigExporter exports kubeconfig certs
 type KubeConfigExporter struct {
+	scanBuffer
 }
 
 // ExportMetrics exports all certs in the passed in kubeconfig file
@@ -20,30 +21,33 @@ func (c *KubeConfigExporter) ExportMetrics(file, nodeName string) error {
 		return err
 	}
 
-	for _, c := range k.Clusters {
+	for _, cluster := range k.Clusters {
 		var metricCollection []certMetric
 
-		if c.Cluster.CertificateAuthorityData != "" {
-			metricCollection, err = secondsToExpiryFromCertAsBase64String(c.Cluster.CertificateAuthorityData)
+		if cluster.Cluster.CertificateAuthorityData != "" {
+			metricCollection, err = secondsToExpiryFromCertAsBase64String(cluster.Cluster.CertificateAuthorityData, Passwords{})
 
 			if err != nil {
 				return err
 			}
-		} else if c.Cluster.CertificateAuthority != "" {
-			certFile := pathToFileFromKubeConfig(c.Cluster.CertificateAuthority, file)
-			metricCollection, err = secondsToExpiryFromCertAsFile(certFile)
+		} else if cluster.Cluster.CertificateAuthority != "" {
+			certFile := pathToFileFromKubeConfig(cluster.Cluster.CertificateAuthority, file)
+			metricCollection, err = secondsToExpiryFromCertAsFile(certFile, Passwords{})
 
 			if err != nil {
 				return err
 			}
 		} else {
-			return fmt.Errorf("Cluster %v does not have CertAuthority or CertAuthorityData", c.Name)
+			return fmt.Errorf("Cluster %v does not have CertAuthority or CertAuthorityData", cluster.Name)
 		}
 
+		c.certsParsed(len(metricCollection))
 		for _, metric := range metricCollection {
-			metrics.KubeConfigExpirySeconds.WithLabelValues(file, "cluster", metric.cn, metric.issuer, c.Name, nodeName).Set(metric.durationUntilExpiry)
-			metrics.KubeConfigNotAfterTimestamp.WithLabelValues(file, "cluster", metric.cn, metric.issuer, c.Name, nodeName).Set(metric.notAfter)
-			metrics.KubeConfigNotBeforeTimestamp.WithLabelValues(file, "cluster", metric.cn, metric.issuer, c.Name, nodeName).Set(metric.notBefore)
+			c.set(metrics.KubeConfigExpirySeconds, metric.durationUntilExpiry, file, "cluster", metric.cn, metric.issuer, cluster.Name, nodeName)
+			c.set(metrics.KubeConfigValidityRemainingRatio, metric.validityRemainingRatio, file, "cluster", metric.cn, metric.issuer, cluster.Name, nodeName)
+			c.set(metrics.KubeConfigNotAfterTimestamp, metric.notAfter, file, "cluster", metric.cn, metric.issuer, cluster.Name, nodeName)
+			c.set(metrics.KubeConfigNotBeforeTimestamp, metric.notBefore, file, "cluster", metric.cn, metric.issuer, cluster.Name, nodeName)
+			c.set(metrics.KubeConfigCertInfo, 1, append([]string{file, "cluster", cluster.Name, nodeName}, metric.infoLabelValues()...)...)
 		}
 	}
 
@@ -51,14 +55,14 @@ func (c *KubeConfigExporter) ExportMetrics(file, nodeName string) error {
 		var metricCollection []certMetric
 
 		if u.User.ClientCertificateData != "" {
-			metricCollection, err = secondsToExpiryFromCertAsBase64String(u.User.ClientCertificateData)
+			metricCollection, err = secondsToExpiryFromCertAsBase64String(u.User.ClientCertificateData, Passwords{})
 
 			if err != nil {
 				return err
 			}
 		} else if u.User.ClientCertificate != "" {
 			certFile := pathToFileFromKubeConfig(u.User.ClientCertificate, file)
-			metricCollection, err = secondsToExpiryFromCertAsFile(certFile)
+			metricCollection, err = secondsToExpiryFromCertAsFile(certFile, Passwords{})
 
 			if err != nil {
 				return err
@@ -67,10 +71,13 @@ func (c *KubeConfigExporter) ExportMetrics(file, nodeName string) error {
 			return fmt.Errorf("User %v does not have ClientCert or ClientCertData", u.Name)
 		}
 
+		c.certsParsed(len(metricCollection))
 		for _, metric := range metricCollection {
-			metrics.KubeConfigExpirySeconds.WithLabelValues(file, "user", metric.cn, metric.issuer, u.Name, nodeName).Set(metric.durationUntilExpiry)
-			metrics.KubeConfigNotAfterTimestamp.WithLabelValues(file, "user", metric.cn, metric.issuer, u.Name, nodeName).Set(metric.notAfter)
-			metrics.KubeConfigNotBeforeTimestamp.WithLabelValues(file, "user", metric.cn, metric.issuer, u.Name, nodeName).Set(metric.notBefore)
+			c.set(metrics.KubeConfigExpirySeconds, metric.durationUntilExpiry, file, "user", metric.cn, metric.issuer, u.Name, nodeName)
+			c.set(metrics.KubeConfigValidityRemainingRatio, metric.validityRemainingRatio, file, "user", metric.cn, metric.issuer, u.Name, nodeName)
+			c.set(metrics.KubeConfigNotAfterTimestamp, metric.notAfter, file, "user", metric.cn, metric.issuer, u.Name, nodeName)
+			c.set(metrics.KubeConfigNotBeforeTimestamp, metric.notBefore, file, "user", metric.cn, metric.issuer, u.Name, nodeName)
+			c.set(metrics.KubeConfigCertInfo, 1, append([]string{file, "user", u.Name, nodeName}, metric.infoLabelValues()...)...)
 		}
 	}
 
@@ -87,7 +94,10 @@ func pathToFileFromKubeConfig(file, kubeConfigFile string) string {
 }
 
 func (c *KubeConfigExporter) ResetMetrics() {
+	c.reset()
 	metrics.KubeConfigExpirySeconds.Reset()
+	metrics.KubeConfigValidityRemainingRatio.Reset()
 	metrics.KubeConfigNotAfterTimestamp.Reset()
 	metrics.KubeConfigNotBeforeTimestamp.Reset()
+	metrics.KubeConfigCertInfo.Reset()
 }
@@ -0,0 +1,95 @@
+package exporters
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+// Reasons reported in the reason label of password_failed
+const (
+	// PasswordReasonIncorrect means none of the candidate passwords matched
+	PasswordReasonIncorrect = "incorrect"
+	// PasswordReasonUnavailable means none of the candidate passwords matched and a configured password source could
+	// not be read
+	PasswordReasonUnavailable = "unavailable"
+)
+
+// errIncorrectPassword is wrapped by the errors of PKCS12 bundles and Java keystores that no password matched
+var errIncorrectPassword = errors.New("password was incorrect")
+
+// Passwords are the candidate passwords of the PKCS12 bundles and Java keystores of a file or object, tried in order
+type Passwords struct {
+	Candidates []string
+	// Specific is the number of leading candidates configured for this file or object, such as the password key of a
+	// secret, rather than for every cert of the checker.  The integrity digest of a Java keystore is only enforced if
+	// one of them is set.
+	Specific int
+	// Err reports the configured password sources that could not be read
+	Err error
+}
+
+// Add appends the candidates and errors of other.  Its specific candidates stay specific if every candidate so far is.
+func (p *Passwords) Add(other Passwords) {
+	if p.Specific == len(p.Candidates) {
+		p.Specific += other.Specific
+	}
+	p.Candidates = append(p.Candidates, other.Candidates...)
+	p.Err = errors.Join(p.Err, other.Err)
+}
+
+// PasswordSources configure where the passwords of PKCS12 bundles and Java keystores are read from.  Every source that
+// is set adds a candidate, tried in the order File, Env and Candidates.  Only the password of File is specific to the
+// cert.
+type PasswordSources struct {
+	// File holds a password.  A relative path is resolved against the directory of the cert.
+	File string
+	// Env is the environment variable holding a password
+	Env string
+	// Candidates are tried after the other sources
+	Candidates []string
+}
+
+// Passwords reads the candidate passwords for the cert at certPath, which is empty for certs that are not files
+func (s PasswordSources) Passwords(certPath string) Passwords {
+	var p Passwords
+	if s.File != "" {
+		file := s.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(certPath), file)
+		}
+		password, err := os.ReadFile(file)
+		if err != nil {
+			p.Err = errors.Join(p.Err, fmt.Errorf("failed to read password file: %w", err))
+		} else {
+			p.Candidates = append(p.Candidates, strings.TrimRight(string(password), "\r\n"))
+			p.Specific = 1
+		}
+	}
+	if s.Env != "" {
+		if password, ok := os.LookupEnv(s.Env); ok {
+			p.Candidates = append(p.Candidates, password)
+		} else {
+			p.Err = errors.Join(p.Err, fmt.Errorf("environment variable %s is not set", s.Env))
+		}
+	}
+	p.Candidates = append(p.Candidates, s.Candidates...)
+	return p
+}
+
+// passwordFailed flags a cert that could not be read because none of its passwords matched.  Other errors are
+// ignored.
+func (b *scanBuffer) passwordFailed(err error, passwords Passwords, source, namespace, name, keyName string) {
+	if !errors.Is(err, errIncorrectPassword) {
+		return
+	}
+	reason := PasswordReasonIncorrect
+	if passwords.Err != nil {
+		reason = PasswordReasonUnavailable
+	}
+	b.set(metrics.PasswordFailed, 1, source, namespace, name, keyName, reason)
+}
@@ -0,0 +1,101 @@
+package exporters
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestPasswordSources_Passwords(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write password file: %v", err)
+	}
+	t.Setenv("CERT_EXPORTER_TEST_PASSWORD", "from-env")
+
+	sources := PasswordSources{File: "password", Env: "CERT_EXPORTER_TEST_PASSWORD", Candidates: []string{"changeit"}}
+	passwords := sources.Passwords(filepath.Join(dir, "keystore.p12"))
+	if want := []string{"from-file", "from-env", "changeit"}; !slices.Equal(passwords.Candidates, want) || passwords.Err != nil {
+		t.Errorf("Expected candidates %q, got %q, %v", want, passwords.Candidates, passwords.Err)
+	}
+	if passwords.Specific != 1 {
+		t.Errorf("Expected only the password file to be specific to the cert, got %d", passwords.Specific)
+	}
+
+	sources = PasswordSources{File: filepath.Join(dir, "missing"), Env: "CERT_EXPORTER_TEST_UNSET", Candidates: []string{"changeit"}}
+	passwords = sources.Passwords("")
+	if want := []string{"changeit"}; !slices.Equal(passwords.Candidates, want) || passwords.Err == nil {
+		t.Errorf("Expected candidates %q and an error for the unreadable sources, got %q, %v", want, passwords.Candidates, passwords.Err)
+	}
+	if passwords.Specific != 0 {
+		t.Errorf("Expected no specific candidates without a password file, got %d", passwords.Specific)
+	}
+}
+
+func TestSecretExporter_PasswordFailed(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "pkcs12", Days: 30})
+	pfxData := testutil.CreatePKCS12Bundle(t, cert, nil, "secret")
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+	exporter.BeginSecret("app", "default")
+
+	if err := exporter.ExportMetrics(pfxData, "wrong.p12", "app", "default", Passwords{Candidates: []string{"wrong"}}, ExpiryThresholds{}); err == nil {
+		t.Error("Expected an error for a wrong password")
+	}
+	unavailable := Passwords{Candidates: []string{"wrong"}, Err: os.ErrNotExist}
+	if err := exporter.ExportMetrics(pfxData, "unavailable.p12", "app", "default", unavailable, ExpiryThresholds{}); err == nil {
+		t.Error("Expected an error for an unavailable password")
+	}
+	if err := exporter.ExportMetrics(pfxData, "right.p12", "app", "default", Passwords{Candidates: []string{"wrong", "secret"}}, ExpiryThresholds{}); err != nil {
+		t.Errorf("Expected the second candidate to decrypt the bundle, got %v", err)
+	}
+	if err := exporter.ExportMetrics([]byte("not a cert"), "invalid.crt", "app", "default", Passwords{}, ExpiryThresholds{}); err == nil {
+		t.Error("Expected an error for an invalid cert")
+	}
+	exporter.EndSecret()
+
+	got := passwordFailures(t, testRegistry)
+	want := map[string]string{"wrong.p12": PasswordReasonIncorrect, "unavailable.p12": PasswordReasonUnavailable}
+	if len(got) != len(want) || got["wrong.p12"] != want["wrong.p12"] || got["unavailable.p12"] != want["unavailable.p12"] {
+		t.Errorf("Expected password_failed reasons %v, got %v", want, got)
+	}
+
+	exporter.DeleteMetrics("app", "default")
+	if got := passwordFailures(t, testRegistry); len(got) != 0 {
+		t.Errorf("Expected no password_failed series after deleting the secret, got %v", got)
+	}
+}
+
+// passwordFailures returns the reason of every password_failed series of secrets by key name
+func passwordFailures(t *testing.T, registry *prometheus.Registry) map[string]string {
+	t.Helper()
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	reasons := map[string]string{}
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_password_failed" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			if labels["source"] != sourceSecrets {
+				continue
+			}
+			reasons[labels["key_name"]] = labels["reason"]
+		}
+	}
+	return reasons
+}
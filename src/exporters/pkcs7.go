@@ -0,0 +1,57 @@
+package exporters
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+// pemTypePKCS7 is the type of PEM blocks holding a PKCS#7 bundle, as written by openssl crl2pkcs7
+const pemTypePKCS7 = "PKCS7"
+
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// pkcs7ContentInfo is the outer structure of a PKCS#7 message, see RFC 2315 section 7
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// parsePKCS7 returns the certificates of a DER encoded PKCS#7 SignedData bundle such as a .p7b or .p7c file.  Only
+// the certificates field is read, signatures and CRLs are ignored.
+func parsePKCS7(der []byte) ([]*x509.Certificate, error) {
+	var info pkcs7ContentInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pkcs7 content info: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("trailing data after pkcs7 content info")
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unsupported pkcs7 content type %s", info.ContentType)
+	}
+
+	var signedData asn1.RawValue
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signedData); err != nil {
+		return nil, fmt.Errorf("failed to parse pkcs7 signed data: %w", err)
+	}
+	if signedData.Class != asn1.ClassUniversal || signedData.Tag != asn1.TagSequence {
+		return nil, fmt.Errorf("pkcs7 signed data is not a sequence")
+	}
+
+	// SignedData is version, digestAlgorithms, contentInfo, then the optional [0] IMPLICIT certificates and [1]
+	// IMPLICIT crls, and finally signerInfos.  Walking the fields and picking the [0] one is all that's needed.
+	fields := signedData.Bytes
+	for len(fields) > 0 {
+		var field asn1.RawValue
+		fields, err = asn1.Unmarshal(fields, &field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pkcs7 signed data: %w", err)
+		}
+		if field.Class == asn1.ClassContextSpecific && field.Tag == 0 {
+			return x509.ParseCertificates(field.Bytes)
+		}
+	}
+	return nil, nil
+}
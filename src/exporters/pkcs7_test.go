@@ -0,0 +1,41 @@
+package exporters
+
+import (
+	"encoding/asn1"
+	"testing"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+)
+
+func TestParsePKCS7(t *testing.T) {
+	root := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "root", Days: 365, IsCA: true})
+	leaf := testutil.GenerateSignedCertificate(t, testutil.CertConfig{CommonName: "leaf", Days: 90}, root)
+
+	certs, err := parsePKCS7(testutil.CreatePKCS7Bundle(t, leaf, root))
+	if err != nil {
+		t.Fatalf("parsePKCS7() error = %v", err)
+	}
+	if len(certs) != 2 || certs[0].Subject.CommonName != "leaf" || certs[1].Subject.CommonName != "root" {
+		t.Errorf("Expected the leaf and root certs, got %d certs", len(certs))
+	}
+
+	certs, err = parsePKCS7(testutil.CreatePKCS7Bundle(t))
+	if err != nil || len(certs) != 0 {
+		t.Errorf("Expected no certs and no error for an empty bundle, got %d certs, %v", len(certs), err)
+	}
+
+	data, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1},
+		Content:     asn1.RawValue{Tag: asn1.TagOctetString, Bytes: []byte("data")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal PKCS7 data: %v", err)
+	}
+	if _, err := parsePKCS7(data); err == nil {
+		t.Error("Expected an error for PKCS7 content that is not signed data")
+	}
+
+	if _, err := parsePKCS7(leaf.Cert.Raw); err == nil {
+		t.Error("Expected an error for a certificate")
+	}
+}
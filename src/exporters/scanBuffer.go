@@ -0,0 +1,266 @@
+package exporters
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+type seriesKey struct {
+	vec    *prometheus.GaugeVec
+	labels string
+}
+
+type seriesValue struct {
+	labels []string
+	value  float64
+}
+
+// seriesOwners counts the scan buffers that published each series.  Overlapping checkers, e.g. two secret checkers
+// selecting the same namespace, publish the same series into the shared gauge vectors, so a series is only deleted
+// once the last buffer publishing it drops it.
+var (
+	seriesOwnersMu sync.Mutex
+	seriesOwners   = map[seriesKey]int{}
+)
+
+// releaseSeries drops the ownership of a buffer of a series and reports whether it was the last owner
+func releaseSeries(key seriesKey) bool {
+	seriesOwners[key]--
+	if seriesOwners[key] > 0 {
+		return false
+	}
+	delete(seriesOwners, key)
+	return true
+}
+
+// publishSeries swaps next in for previous: it takes ownership of the series new in next and deletes those of previous
+// that next does not hold, unless another buffer still publishes them.
+func publishSeries(previous, next map[seriesKey]seriesValue) {
+	metrics.Publish(func() {
+		seriesOwnersMu.Lock()
+		defer seriesOwnersMu.Unlock()
+
+		for key, s := range previous {
+			if _, ok := next[key]; !ok && releaseSeries(key) {
+				key.vec.DeleteLabelValues(s.labels...)
+			}
+		}
+		for key, s := range next {
+			if _, ok := previous[key]; !ok {
+				seriesOwners[key]++
+			}
+			key.vec.WithLabelValues(s.labels...).Set(s.value)
+		}
+	})
+}
+
+// scanBuffer stages the series produced by a scan so they can be swapped in at once when the scan
+// completes.  Outside of a scan the series of a single object can be staged the same way between
+// beginObject and endObject, and other values are written straight through to the gauge vectors.  It
+// also reports every scan to the ScanHealth set with SetScanHealth.  The zero value is ready to use.
+type scanBuffer struct {
+	mu        sync.Mutex
+	scanning  bool
+	failed    bool
+	pending   map[seriesKey]seriesValue
+	published map[seriesKey]seriesValue
+	health    *ScanHealth
+
+	// series staged and published outside of a scan, by object
+	object        string
+	objectPending map[seriesKey]seriesValue
+	objects       map[string]map[seriesKey]seriesValue
+}
+
+// SetScanHealth sets where the scans through this exporter are reported.  Call it before the first scan.
+func (b *scanBuffer) SetScanHealth(h *ScanHealth) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.health = h
+}
+
+// BeginScan starts staging series.  Until EndScan is called scrapes keep seeing the previous scan.
+func (b *scanBuffer) BeginScan() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.scanning = true
+	b.failed = false
+	b.pending = map[seriesKey]seriesValue{}
+	b.health.begin()
+}
+
+// EndScan publishes the staged series and removes the series of the previous scan that were not seen again, unless
+// another buffer still publishes them.
+func (b *scanBuffer) EndScan() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.scanning {
+		return
+	}
+
+	publishSeries(b.published, b.pending)
+	b.published = b.pending
+	b.pending = nil
+	b.scanning = false
+	b.health.end()
+}
+
+// AbortScan discards the staged series and keeps the previous scan, e.g. because the current one could not list
+// every object and would otherwise delete the series of those it missed.  The scan health reports it as failed.
+func (b *scanBuffer) AbortScan() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.scanning {
+		return
+	}
+
+	b.pending = nil
+	b.scanning = false
+	b.health.abort()
+}
+
+// ScanFailed reports whether an API error was counted since BeginScan, so the scan may have missed objects.
+func (b *scanBuffer) ScanFailed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.scanning && b.failed
+}
+
+// Clear removes every series published by previous scans or objects that no other buffer publishes and the scan health
+// series, e.g. once the checker feeding the exporter was stopped.
+func (b *scanBuffer) Clear() {
+	b.BeginScan()
+	b.EndScan()
+	b.deleteObjects(func(string) bool { return true })
+	b.health.clear()
+}
+
+// beginObject starts staging the series of a single object outside of a scan, e.g. of a secret the watcher saw
+// change.  Until endObject is called scrapes keep seeing the series the object published before.  Objects are staged
+// one at a time.
+func (b *scanBuffer) beginObject(object string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.object = object
+	b.objectPending = map[seriesKey]seriesValue{}
+}
+
+// endObject publishes the staged series of the object and removes those it published before that were not staged
+// again, unless another buffer still publishes them.
+func (b *scanBuffer) endObject() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.objectPending == nil {
+		return
+	}
+
+	publishSeries(b.objects[b.object], b.objectPending)
+	if len(b.objectPending) == 0 {
+		delete(b.objects, b.object)
+	} else {
+		if b.objects == nil {
+			b.objects = map[string]map[seriesKey]seriesValue{}
+		}
+		b.objects[b.object] = b.objectPending
+	}
+	b.object = ""
+	b.objectPending = nil
+}
+
+// deleteObjects removes the series published by the matching objects, unless another buffer still publishes them.
+func (b *scanBuffer) deleteObjects(match func(object string) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for object, series := range b.objects {
+		if match(object) {
+			publishSeries(series, nil)
+			delete(b.objects, object)
+		}
+	}
+}
+
+// BeginSync and EndSync report a scan to the scan health without staging series, for checkers that keep
+// their series up to date themselves such as the secret watcher while its informers sync.
+func (b *scanBuffer) BeginSync() {
+	b.health.begin()
+}
+
+// EndSync finishes the scan started by BeginSync
+func (b *scanBuffer) EndSync() {
+	b.health.end()
+}
+
+// ObjectScanned counts a file, secret or other object looked at by the current scan.
+func (b *scanBuffer) ObjectScanned() {
+	b.health.objectScanned()
+}
+
+// ScanError counts an error of the checker feeding the exporter.  reason is one of the Reason constants.
+func (b *scanBuffer) ScanError(reason string) {
+	metrics.ErrorTotal.Inc()
+	b.health.error(reason)
+
+	if reason == ReasonAPIError {
+		b.mu.Lock()
+		b.failed = b.failed || b.scanning
+		b.mu.Unlock()
+	}
+}
+
+// certsParsed counts certs parsed by the current scan.
+func (b *scanBuffer) certsParsed(n int) {
+	b.health.certsParsed(n)
+}
+
+// set records a gauge value, staging it if a scan is in progress or an object is being staged.
+func (b *scanBuffer) set(vec *prometheus.GaugeVec, value float64, labels ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pending := b.pending
+	if !b.scanning {
+		pending = b.objectPending
+	}
+	if pending == nil {
+		vec.WithLabelValues(labels...).Set(value)
+		return
+	}
+
+	key := seriesKey{vec: vec, labels: strings.Join(labels, "\xff")}
+	pending[key] = seriesValue{labels: labels, value: value}
+}
+
+// reset forgets everything staged or published by previous scans.
+func (b *scanBuffer) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seriesOwnersMu.Lock()
+	for key := range b.published {
+		releaseSeries(key)
+	}
+	for _, series := range b.objects {
+		for key := range series {
+			releaseSeries(key)
+		}
+	}
+	seriesOwnersMu.Unlock()
+
+	b.published = nil
+	b.objects = nil
+	if b.scanning {
+		b.pending = map[seriesKey]seriesValue{}
+	}
+}
@@ -0,0 +1,161 @@
+package exporters
+
+import (
+	"testing"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestScanBuffer_KeepsPreviousScanUntilEndScan(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	oldCert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "old-cert", Organization: "test-org", Country: "US", Province: "CA", Days: 30,
+	})
+	newCert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "new-cert", Organization: "test-org", Country: "US", Province: "CA", Days: 60,
+	})
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+
+	exporter.BeginScan()
+	if err := exporter.ExportMetrics(oldCert.CertPEM, "tls.crt", "old-secret", "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	if cns := gatherSecretCNs(t, testRegistry); len(cns) != 0 {
+		t.Errorf("Expected no series before the first scan ends, got %v", cns)
+	}
+	exporter.EndScan()
+
+	if cns := gatherSecretCNs(t, testRegistry); !cns["old-cert"] || len(cns) != 1 {
+		t.Errorf("Expected only old-cert after the first scan, got %v", cns)
+	}
+
+	exporter.BeginScan()
+	if err := exporter.ExportMetrics(newCert.CertPEM, "tls.crt", "new-secret", "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	if cns := gatherSecretCNs(t, testRegistry); !cns["old-cert"] || len(cns) != 1 {
+		t.Errorf("Expected the previous scan to be served while scanning, got %v", cns)
+	}
+	exporter.EndScan()
+
+	if cns := gatherSecretCNs(t, testRegistry); !cns["new-cert"] || len(cns) != 1 {
+		t.Errorf("Expected only new-cert after the second scan, got %v", cns)
+	}
+}
+
+func TestScanBuffer_AbortScanKeepsPreviousScan(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	oldCert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "old-cert", Days: 30})
+	newCert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "new-cert", Days: 60})
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+
+	exporter.BeginScan()
+	if err := exporter.ExportMetrics(oldCert.CertPEM, "tls.crt", "old-secret", "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	exporter.EndScan()
+
+	exporter.BeginScan()
+	if err := exporter.ExportMetrics(newCert.CertPEM, "tls.crt", "new-secret", "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	exporter.ScanError(ReasonAPIError)
+	if !exporter.ScanFailed() {
+		t.Error("Expected the scan to fail after an API error")
+	}
+	exporter.AbortScan()
+
+	if cns := gatherSecretCNs(t, testRegistry); !cns["old-cert"] || len(cns) != 1 {
+		t.Errorf("Expected only old-cert after the aborted scan, got %v", cns)
+	}
+
+	exporter.BeginScan()
+	if exporter.ScanFailed() {
+		t.Error("Expected a new scan to start without failure")
+	}
+	exporter.EndScan()
+}
+
+func TestScanBuffer_WritesThroughOutsideOfScan(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "direct-cert", Organization: "test-org", Country: "US", Province: "CA", Days: 30,
+	})
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+
+	if err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", "direct-secret", "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	if cns := gatherSecretCNs(t, testRegistry); !cns["direct-cert"] {
+		t.Errorf("Expected direct-cert to be exported immediately, got %v", cns)
+	}
+
+	// Ending a scan that was never started must not touch the exported series.
+	exporter.EndScan()
+	if cns := gatherSecretCNs(t, testRegistry); !cns["direct-cert"] {
+		t.Errorf("Expected direct-cert to survive EndScan without BeginScan, got %v", cns)
+	}
+}
+
+func gatherSecretCNs(t *testing.T, registry *prometheus.Registry) map[string]bool {
+	t.Helper()
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	cns := map[string]bool{}
+	for _, mf := range mfs {
+		if mf.GetName() == "cert_exporter_secret_expires_in_seconds" {
+			for _, metric := range mf.GetMetric() {
+				cns[getLabelMap(metric)["cn"]] = true
+			}
+		}
+	}
+	return cns
+}
+
+func TestScanBuffer_ClearOnlyRemovesOwnSeries(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	certA := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "cert-a", Days: 30})
+	certB := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "cert-b", Days: 30})
+
+	exporterA := &SecretExporter{}
+	exporterB := &SecretExporter{}
+	exporterA.ResetMetrics()
+
+	for _, e := range []struct {
+		exporter *SecretExporter
+		cert     []byte
+		secret   string
+	}{{exporterA, certA.CertPEM, "secret-a"}, {exporterB, certB.CertPEM, "secret-b"}} {
+		e.exporter.BeginScan()
+		if err := e.exporter.ExportMetrics(e.cert, "tls.crt", e.secret, "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+			t.Fatalf("Failed to export metrics: %v", err)
+		}
+		e.exporter.EndScan()
+	}
+
+	exporterA.Clear()
+
+	if cns := gatherSecretCNs(t, testRegistry); !cns["cert-b"] || len(cns) != 1 {
+		t.Errorf("Expected only cert-b after clearing the first exporter, got %v", cns)
+	}
+}
@@ -0,0 +1,169 @@
+package exporters
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+// Reasons reported in the reason label of errors_total
+const (
+	ReasonAPIError    = "api_error"
+	ReasonObjectError = "object_error"
+	ReasonParseError  = "parse_error"
+	ReasonGlobError   = "glob_error"
+	ReasonProbeError  = "probe_error"
+)
+
+// ScanHealth exports how the scans of a single checker went: when the last scan succeeded, how long scans take,
+// how many objects and certs the last scan saw and which errors the checker ran into.  A scan succeeds if it
+// finishes without errors other than objects that could not be fetched, certs that could not be parsed or endpoints
+// that could not be probed, which are problems of a single object rather than of the checker.  A nil *ScanHealth records nothing.
+type ScanHealth struct {
+	mu       sync.Mutex
+	checker  string
+	source   string
+	scanning bool
+	started  time.Time
+	objects  int
+	certs    int
+	failed   bool
+	created  time.Time
+	lastScan time.Time
+}
+
+// NewScanHealth is a factory method that returns a new ScanHealth for the named checker.  source is the kind of
+// checker, e.g. secrets or certs.
+func NewScanHealth(checker, source string) *ScanHealth {
+	return &ScanHealth{
+		checker: checker,
+		source:  source,
+		created: time.Now(),
+	}
+}
+
+// Scanned reports whether the checker has finished at least one scan
+func (h *ScanHealth) Scanned() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return !h.lastScan.IsZero()
+}
+
+// SinceLastScan returns how long ago the checker finished its last scan, or was created if it has not finished one
+func (h *ScanHealth) SinceLastScan() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.lastScan.IsZero() {
+		return time.Since(h.created)
+	}
+	return time.Since(h.lastScan)
+}
+
+func (h *ScanHealth) begin() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.scanning = true
+	h.started = time.Now()
+	h.objects = 0
+	h.certs = 0
+	h.failed = false
+}
+
+func (h *ScanHealth) end() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.scanning {
+		return
+	}
+	h.scanning = false
+
+	now := time.Now()
+	h.lastScan = now
+	metrics.ScanDurationSeconds.WithLabelValues(h.checker, h.source).Observe(now.Sub(h.started).Seconds())
+	metrics.ScanObjects.WithLabelValues(h.checker, h.source).Set(float64(h.objects))
+	metrics.ScanCertsParsed.WithLabelValues(h.checker, h.source).Set(float64(h.certs))
+	if !h.failed {
+		metrics.ScanLastSuccessTimestamp.WithLabelValues(h.checker, h.source).Set(float64(now.Unix()))
+	}
+}
+
+// abort fails and ends the current scan
+func (h *ScanHealth) abort() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.failed = true
+	h.mu.Unlock()
+
+	h.end()
+}
+
+// objectScanned counts an object looked at by the current scan
+func (h *ScanHealth) objectScanned() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.scanning {
+		h.objects++
+	}
+}
+
+// certsParsed counts certs parsed by the current scan
+func (h *ScanHealth) certsParsed(n int) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.scanning {
+		h.certs += n
+	}
+}
+
+// error counts an error and fails the current scan unless it is about a single object, cert or endpoint
+func (h *ScanHealth) error(reason string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	metrics.ScanErrorsTotal.WithLabelValues(h.checker, h.source, reason).Inc()
+	if h.scanning && reason != ReasonObjectError && reason != ReasonParseError && reason != ReasonProbeError {
+		h.failed = true
+	}
+}
+
+// clear removes every series of the checker
+func (h *ScanHealth) clear() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	labels := prometheus.Labels{"checker": h.checker, "source": h.source}
+	metrics.ScanLastSuccessTimestamp.DeletePartialMatch(labels)
+	metrics.ScanDurationSeconds.DeletePartialMatch(labels)
+	metrics.ScanObjects.DeletePartialMatch(labels)
+	metrics.ScanCertsParsed.DeletePartialMatch(labels)
+	metrics.ScanErrorsTotal.DeletePartialMatch(labels)
+}
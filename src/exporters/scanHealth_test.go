@@ -0,0 +1,106 @@
+package exporters
+
+import (
+	"testing"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestScanHealth(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "health-cert", Days: 30})
+
+	exporter := &SecretExporter{}
+	exporter.SetScanHealth(NewScanHealth("team-a", "secrets"))
+
+	exporter.BeginScan()
+	exporter.ObjectScanned()
+	if err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", "good", "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	exporter.ObjectScanned()
+	if err := exporter.ExportMetrics([]byte("not a cert"), "tls.crt", "bad", "test-namespace", Passwords{}, ExpiryThresholds{}); err == nil {
+		t.Fatal("Expected an error for an invalid cert")
+	}
+	exporter.ScanError(ReasonParseError)
+	exporter.EndScan()
+
+	series := gatherScanHealth(t, testRegistry)
+	if got := series["cert_exporter_objects_scanned"]; got != 2 {
+		t.Errorf("Expected 2 objects scanned, got %v", got)
+	}
+	if got := series["cert_exporter_certs_parsed"]; got != 1 {
+		t.Errorf("Expected 1 cert parsed, got %v", got)
+	}
+	if got := series["cert_exporter_errors_total/parse_error"]; got != 1 {
+		t.Errorf("Expected 1 parse error, got %v", got)
+	}
+	if got := series["cert_exporter_scan_duration_seconds"]; got != 1 {
+		t.Errorf("Expected 1 observed scan, got %v", got)
+	}
+	if series["cert_exporter_last_scan_success_timestamp"] == 0 {
+		t.Error("Expected a parse error not to fail the scan")
+	}
+
+	exporter.Clear()
+
+	if series := gatherScanHealth(t, testRegistry); len(series) != 0 {
+		t.Errorf("Expected no scan health series after clearing, got %v", series)
+	}
+}
+
+func TestScanHealth_FailedScan(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	exporter := &ConfigMapExporter{}
+	exporter.SetScanHealth(NewScanHealth("failing", "configmaps"))
+
+	exporter.BeginScan()
+	exporter.ScanError(ReasonAPIError)
+	exporter.EndScan()
+
+	series := gatherScanHealth(t, testRegistry)
+	if _, ok := series["cert_exporter_last_scan_success_timestamp"]; ok {
+		t.Error("Expected no successful scan after an api error")
+	}
+	if got := series["cert_exporter_errors_total/api_error"]; got != 1 {
+		t.Errorf("Expected 1 api error, got %v", got)
+	}
+
+	exporter.Clear()
+}
+
+// gatherScanHealth returns the value of every scan health series, keyed by metric name and, for errors, reason.
+// Histograms are reported by their sample count.
+func gatherScanHealth(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	series := map[string]float64{}
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "cert_exporter_objects_scanned", "cert_exporter_certs_parsed", "cert_exporter_last_scan_success_timestamp":
+			for _, metric := range mf.GetMetric() {
+				series[mf.GetName()] = metric.GetGauge().GetValue()
+			}
+		case "cert_exporter_scan_duration_seconds":
+			for _, metric := range mf.GetMetric() {
+				series[mf.GetName()] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		case "cert_exporter_errors_total":
+			for _, metric := range mf.GetMetric() {
+				series[mf.GetName()+"/"+getLabelMap(metric)["reason"]] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return series
+}
@@ -1,31 +1,102 @@
 package exporters
 
 import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/joe-elliott/cert-exporter/src/metrics"
 )
 
 // SecretExporter exports PEM file certs
 type SecretExporter struct {
+	scanBuffer
 }
 
 // ExportMetrics exports the provided PEM file
-func (c *SecretExporter) ExportMetrics(bytes []byte, keyName, secretName, secretNamespace, certPassword string) error {
-	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, certPassword)
+func (c *SecretExporter) ExportMetrics(bytes []byte, keyName, secretName, secretNamespace string, passwords Passwords, thresholds ExpiryThresholds) error {
+	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, passwords)
 	if err != nil {
+		c.passwordFailed(err, passwords, sourceSecrets, secretNamespace, secretName, keyName)
 		return err
 	}
 
+	c.certsParsed(len(metricCollection))
 	for _, metric := range metricCollection {
-		metrics.SecretExpirySeconds.WithLabelValues(keyName, metric.issuer, metric.cn, secretName, secretNamespace).Set(metric.durationUntilExpiry)
-		metrics.SecretNotAfterTimestamp.WithLabelValues(keyName, metric.issuer, metric.cn, secretName, secretNamespace).Set(metric.notAfter)
-		metrics.SecretNotBeforeTimestamp.WithLabelValues(keyName, metric.issuer, metric.cn, secretName, secretNamespace).Set(metric.notBefore)
+		c.set(metrics.SecretExpirySeconds, metric.durationUntilExpiry, keyName, metric.issuer, metric.cn, secretName, secretNamespace)
+		c.set(metrics.SecretValidityRemainingRatio, metric.validityRemainingRatio, keyName, metric.issuer, metric.cn, secretName, secretNamespace)
+		c.set(metrics.SecretNotAfterTimestamp, metric.notAfter, keyName, metric.issuer, metric.cn, secretName, secretNamespace)
+		c.set(metrics.SecretNotBeforeTimestamp, metric.notBefore, keyName, metric.issuer, metric.cn, secretName, secretNamespace)
+		c.set(metrics.SecretCertInfo, 1, append([]string{keyName, secretName, secretNamespace}, metric.infoLabelValues()...)...)
+		c.setStatus(metric, thresholds, sourceSecrets, secretNamespace, secretName, keyName)
 	}
 
 	return nil
 }
 
+// ExportKeypair exports whether the private key of a kubernetes.io/tls secret belongs to its cert.  It returns the
+// error of a cert or key that could not be parsed.  Encrypted keys are only reported in the reason label.
+func (c *SecretExporter) ExportKeypair(certBytes, keyBytes []byte, secretName, secretNamespace string) error {
+	reason, err := keypairMatch(certBytes, keyBytes)
+	value := 0.0
+	if reason == KeypairReasonMatch {
+		value = 1
+	}
+	c.set(metrics.SecretKeypairMatch, value, secretName, secretNamespace, reason)
+	if reason == KeypairReasonEncryptedKey {
+		return nil
+	}
+	return err
+}
+
+// ExportLabels exports the labels and annotations copied from a secret.  names are the prometheus labels values are
+// copied to.
+func (c *SecretExporter) ExportLabels(names, values []string, secretName, secretNamespace string) {
+	c.set(metrics.SecretLabels.With(names), 1, append([]string{secretName, secretNamespace}, values...)...)
+}
+
 func (c *SecretExporter) ResetMetrics() {
+	c.reset()
 	metrics.SecretExpirySeconds.Reset()
+	metrics.SecretValidityRemainingRatio.Reset()
 	metrics.SecretNotAfterTimestamp.Reset()
 	metrics.SecretNotBeforeTimestamp.Reset()
+	metrics.SecretCertInfo.Reset()
+	metrics.SecretLabels.Reset()
+	metrics.SecretKeypairMatch.Reset()
+	metrics.SecretChainValid.Reset()
+	metrics.SecretChainLength.Reset()
+	metrics.SecretChainExpirySeconds.Reset()
+	metrics.CertStatus.DeletePartialMatch(prometheus.Labels{"source": sourceSecrets})
+	metrics.PasswordFailed.DeletePartialMatch(prometheus.Labels{"source": sourceSecrets})
+}
+
+// BeginSecret starts staging the series of a single secret outside of a scan, e.g. after the watcher saw it change.
+// EndSecret swaps them in for the series the secret published before.  Secrets are staged one at a time.
+func (c *SecretExporter) BeginSecret(secretName, secretNamespace string) {
+	c.beginObject(secretObject(secretName, secretNamespace))
+}
+
+// EndSecret publishes the series staged since BeginSecret
+func (c *SecretExporter) EndSecret() {
+	c.endObject()
+}
+
+// DeleteMetrics removes the series published for the given secret through BeginSecret, unless another exporter still
+// publishes them
+func (c *SecretExporter) DeleteMetrics(secretName, secretNamespace string) {
+	object := secretObject(secretName, secretNamespace)
+	c.deleteObjects(func(o string) bool { return o == object })
+}
+
+// DeleteNamespaceMetrics removes the series published through BeginSecret for secrets in the given namespace, unless
+// another exporter still publishes them
+func (c *SecretExporter) DeleteNamespaceMetrics(secretNamespace string) {
+	prefix := secretObject("", secretNamespace)
+	c.deleteObjects(func(o string) bool { return strings.HasPrefix(o, prefix) })
+}
+
+// secretObject returns the object the series of a secret are published by
+func secretObject(secretName, secretNamespace string) string {
+	return secretNamespace + "/" + secretName
 }
@@ -26,7 +26,7 @@ func TestSecretExporter_ExportMetrics(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export metrics
-	err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", "test-secret", "test-namespace", "")
+	err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", "test-secret", "test-namespace", Passwords{}, ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
@@ -40,6 +40,7 @@ func TestSecretExporter_ExportMetrics(t *testing.T) {
 	foundExpiry := false
 	foundNotAfter := false
 	foundNotBefore := false
+	foundRatio := false
 
 	for _, mf := range mfs {
 		switch mf.GetName() {
@@ -54,6 +55,17 @@ func TestSecretExporter_ExportMetrics(t *testing.T) {
 					}
 				}
 			}
+		case "cert_exporter_secret_validity_remaining_ratio":
+			for _, metric := range mf.GetMetric() {
+				labels := getLabelMap(metric)
+				if labels["cn"] == "test-secret" {
+					foundRatio = true
+					value := metric.GetGauge().GetValue()
+					if value <= 0.9 || value > 1 {
+						t.Errorf("Expected nearly all of the validity to be left, got %v", value)
+					}
+				}
+			}
 		case "cert_exporter_secret_not_after_timestamp":
 			for _, metric := range mf.GetMetric() {
 				labels := getLabelMap(metric)
@@ -80,6 +92,9 @@ func TestSecretExporter_ExportMetrics(t *testing.T) {
 	if !foundNotBefore {
 		t.Error("Expected to find secret_not_before_timestamp metric")
 	}
+	if !foundRatio {
+		t.Error("Expected to find secret_validity_remaining_ratio metric")
+	}
 }
 
 func TestSecretExporter_ExportMetrics_Bundle(t *testing.T) {
@@ -112,7 +127,7 @@ func TestSecretExporter_ExportMetrics_Bundle(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export bundle metrics
-	err := exporter.ExportMetrics(bundle, "ca-bundle.crt", "bundle-secret", "test-namespace", "")
+	err := exporter.ExportMetrics(bundle, "ca-bundle.crt", "bundle-secret", "test-namespace", Passwords{}, ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export bundle metrics: %v", err)
 	}
@@ -180,7 +195,7 @@ func TestSecretExporter_ExportMetrics_PKCS12(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export PKCS12 metrics
-	err := exporter.ExportMetrics(pfxData, "keystore.p12", "pkcs12-secret", "test-namespace", "")
+	err := exporter.ExportMetrics(pfxData, "keystore.p12", "pkcs12-secret", "test-namespace", Passwords{}, ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export PKCS12 metrics: %v", err)
 	}
@@ -249,7 +264,7 @@ func TestSecretExporter_ExportMetrics_PKCS12WithPassword(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Export PKCS12 metrics with correct password
-	err := exporter.ExportMetrics(pfxData, "secure.p12", "secure-secret", "test-namespace", password)
+	err := exporter.ExportMetrics(pfxData, "secure.p12", "secure-secret", "test-namespace", Passwords{Candidates: []string{password}}, ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export PKCS12 metrics with password: %v", err)
 	}
@@ -285,7 +300,7 @@ func TestSecretExporter_ExportMetrics_InvalidCert(t *testing.T) {
 	exporter.ResetMetrics()
 
 	// Try to export invalid certificate data
-	err := exporter.ExportMetrics([]byte("not a valid certificate"), "invalid.crt", "invalid-secret", "test-namespace", "")
+	err := exporter.ExportMetrics([]byte("not a valid certificate"), "invalid.crt", "invalid-secret", "test-namespace", Passwords{}, ExpiryThresholds{})
 	if err == nil {
 		t.Error("Expected error when exporting invalid certificate data")
 	}
@@ -303,7 +318,7 @@ func TestSecretExporter_ResetMetrics(t *testing.T) {
 	exporter := &SecretExporter{}
 	exporter.ResetMetrics()
 
-	err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", "reset-secret", "test-namespace", "")
+	err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", "reset-secret", "test-namespace", Passwords{}, ExpiryThresholds{})
 	if err != nil {
 		t.Fatalf("Failed to export metrics: %v", err)
 	}
@@ -344,3 +359,125 @@ func TestSecretExporter_ResetMetrics(t *testing.T) {
 		}
 	}
 }
+
+func TestSecretExporter_DeleteMetrics(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{
+		CommonName: "delete-test", Organization: "test-org", Country: "US", Province: "CA", Days: 30,
+	})
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+
+	for _, secret := range []struct{ name, namespace string }{
+		{"keep-secret", "ns-a"},
+		{"delete-secret", "ns-a"},
+		{"other-secret", "ns-b"},
+	} {
+		exporter.BeginSecret(secret.name, secret.namespace)
+		if err := exporter.ExportMetrics(cert.CertPEM, "tls.crt", secret.name, secret.namespace, Passwords{}, ExpiryThresholds{}); err != nil {
+			t.Fatalf("Failed to export metrics: %v", err)
+		}
+		exporter.EndSecret()
+	}
+
+	// Another checker selecting ns-a publishes keep-secret as well
+	other := &SecretExporter{}
+	other.BeginScan()
+	if err := other.ExportMetrics(cert.CertPEM, "tls.crt", "keep-secret", "ns-a", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+	other.EndScan()
+
+	secretsInRegistry := func() map[string]bool {
+		mfs, err := testRegistry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+		found := map[string]bool{}
+		for _, mf := range mfs {
+			if mf.GetName() == "cert_exporter_secret_expires_in_seconds" {
+				for _, metric := range mf.GetMetric() {
+					labels := getLabelMap(metric)
+					found[labels["secret_namespace"]+"/"+labels["secret_name"]] = true
+				}
+			}
+		}
+		return found
+	}
+
+	exporter.DeleteMetrics("delete-secret", "ns-a")
+	found := secretsInRegistry()
+	if found["ns-a/delete-secret"] {
+		t.Error("Expected metrics for ns-a/delete-secret to be deleted")
+	}
+	if !found["ns-a/keep-secret"] || !found["ns-b/other-secret"] {
+		t.Errorf("Expected metrics for other secrets to be kept, got %v", found)
+	}
+
+	exporter.DeleteNamespaceMetrics("ns-a")
+	found = secretsInRegistry()
+	if !found["ns-a/keep-secret"] {
+		t.Error("Expected metrics for ns-a/keep-secret to be kept while another exporter publishes them")
+	}
+	if !found["ns-b/other-secret"] {
+		t.Error("Expected metrics for namespace ns-b to be kept")
+	}
+
+	other.Clear()
+	found = secretsInRegistry()
+	if found["ns-a/keep-secret"] {
+		t.Error("Expected metrics for namespace ns-a to be deleted once no exporter publishes them")
+	}
+	exporter.Clear()
+	if found := secretsInRegistry(); len(found) != 0 {
+		t.Errorf("Expected Clear to delete the metrics of every secret, got %v", found)
+	}
+}
+
+func TestSecretExporter_ExportMetrics_CertInfo(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	// Two SAN-only certs share an empty CN but must stay distinguishable.
+	first := testutil.GenerateCertificate(t, testutil.CertConfig{DNSNames: []string{"a.example.com"}, Days: 30})
+	second := testutil.GenerateCertificate(t, testutil.CertConfig{DNSNames: []string{"b.example.com"}, Days: 30})
+
+	exporter := &SecretExporter{}
+	exporter.ResetMetrics()
+
+	if err := exporter.ExportMetrics(testutil.CreateCertBundle(first, second), "tls.crt", "info-secret", "test-namespace", Passwords{}, ExpiryThresholds{}); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+
+	mfs, err := testRegistry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	dnsNames := map[string]bool{}
+	for _, mf := range mfs {
+		if mf.GetName() != "cert_exporter_secret_cert_info" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			labels := getLabelMap(metric)
+			if labels["secret_name"] != "info-secret" || labels["key_name"] != "tls.crt" {
+				continue
+			}
+			if metric.GetGauge().GetValue() != 1 {
+				t.Errorf("Expected cert_info value 1, got %v", metric.GetGauge().GetValue())
+			}
+			if len(labels["fingerprint_sha256"]) != 64 {
+				t.Errorf("Expected a sha256 fingerprint, got %q", labels["fingerprint_sha256"])
+			}
+			dnsNames[labels["dns_names"]] = true
+		}
+	}
+
+	if !dnsNames["a.example.com"] || !dnsNames["b.example.com"] {
+		t.Errorf("Expected a cert_info series for each SAN-only cert, got %v", dnsNames)
+	}
+}
@@ -6,26 +6,40 @@ import (
 
 // WebhookExporter exports PEM file certs
 type WebhookExporter struct {
+	scanBuffer
 }
 
 // ExportMetrics exports the provided PEM file
 func (c *WebhookExporter) ExportMetrics(bytes []byte, typeName, webhookName, admissionReviewVersionName string) error {
-	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, "")
+	metricCollection, err := secondsToExpiryFromCertAsBytes(bytes, Passwords{})
 	if err != nil {
 		return err
 	}
 
+	c.certsParsed(len(metricCollection))
 	for _, metric := range metricCollection {
-		metrics.WebhookExpirySeconds.WithLabelValues(typeName, metric.issuer, metric.cn, webhookName, admissionReviewVersionName).Set(metric.durationUntilExpiry)
-		metrics.WebhookNotAfterTimestamp.WithLabelValues(typeName, metric.issuer, metric.cn, webhookName, admissionReviewVersionName).Set(metric.notAfter)
-		metrics.WebhookNotBeforeTimestamp.WithLabelValues(typeName, metric.issuer, metric.cn, webhookName, admissionReviewVersionName).Set(metric.notBefore)
+		c.set(metrics.WebhookExpirySeconds, metric.durationUntilExpiry, typeName, metric.issuer, metric.cn, webhookName, admissionReviewVersionName)
+		c.set(metrics.WebhookValidityRemainingRatio, metric.validityRemainingRatio, typeName, metric.issuer, metric.cn, webhookName, admissionReviewVersionName)
+		c.set(metrics.WebhookNotAfterTimestamp, metric.notAfter, typeName, metric.issuer, metric.cn, webhookName, admissionReviewVersionName)
+		c.set(metrics.WebhookNotBeforeTimestamp, metric.notBefore, typeName, metric.issuer, metric.cn, webhookName, admissionReviewVersionName)
+		c.set(metrics.WebhookCertInfo, 1, append([]string{typeName, webhookName, admissionReviewVersionName}, metric.infoLabelValues()...)...)
 	}
 
 	return nil
 }
 
+// ExportLabels exports the labels and annotations copied from a webhook configuration.  names are the prometheus labels values are
+// copied to.
+func (c *WebhookExporter) ExportLabels(names, values []string, typeName, webhookName string) {
+	c.set(metrics.WebhookLabels.With(names), 1, append([]string{typeName, webhookName}, values...)...)
+}
+
 func (c *WebhookExporter) ResetMetrics() {
+	c.reset()
 	metrics.WebhookExpirySeconds.Reset()
+	metrics.WebhookValidityRemainingRatio.Reset()
 	metrics.WebhookNotAfterTimestamp.Reset()
 	metrics.WebhookNotBeforeTimestamp.Reset()
+	metrics.WebhookCertInfo.Reset()
+	metrics.WebhookLabels.Reset()
 }
@@ -0,0 +1,90 @@
+package kubeclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cmclientset "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Client holds the clients built from a single kubeconfig
+type Client struct {
+	Config      *rest.Config
+	Kubernetes  kubernetes.Interface
+	CertManager cmclientset.Interface
+	Informers   *Informers
+}
+
+// Options tune the clients built by Clients.  Zero values keep the client-go defaults.
+type Options struct {
+	// QPS and Burst limit the requests each client makes against the API server
+	QPS   float32
+	Burst int
+	// UserAgent is sent with every request
+	UserAgent string
+}
+
+// Clients builds one Client per kubeconfig and shares it between every checker using that kubeconfig.  An empty
+// kubeconfig path means the in-cluster config.
+type Clients struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	options Options
+	clients map[string]*Client
+}
+
+// NewClients is a factory method that returns a new Clients.  The informers of the clients run until ctx is cancelled.
+func NewClients(ctx context.Context, options Options) *Clients {
+	return &Clients{
+		ctx:     ctx,
+		options: options,
+		clients: map[string]*Client{},
+	}
+}
+
+// Get returns the Client for a kubeconfig, building it on first use
+func (c *Clients) Get(kubeconfigPath string) (*Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.clients[kubeconfigPath]; ok {
+		return client, nil
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("building kubeconfig %q: %w", kubeconfigPath, err)
+	}
+	if c.options.QPS > 0 {
+		config.QPS = c.options.QPS
+	}
+	if c.options.Burst > 0 {
+		config.Burst = c.options.Burst
+	}
+	if c.options.UserAgent != "" {
+		config.UserAgent = c.options.UserAgent
+	}
+
+	kubernetesClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating kubernetes client: %w", err)
+	}
+
+	certManagerClient, err := cmclientset.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating cert-manager client: %w", err)
+	}
+
+	client := &Client{
+		Config:      config,
+		Kubernetes:  kubernetesClient,
+		CertManager: certManagerClient,
+		Informers:   NewInformers(kubernetesClient, c.ctx.Done()),
+	}
+	c.clients[kubeconfigPath] = client
+	return client, nil
+}
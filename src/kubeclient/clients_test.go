@@ -0,0 +1,58 @@
+package kubeclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test
+`
+
+func TestClients_Get(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+
+	clients := NewClients(context.Background(), Options{QPS: 50, Burst: 100, UserAgent: "cert-exporter/test"})
+
+	first, err := clients.Get(path)
+	if err != nil {
+		t.Fatalf("Failed to build client: %v", err)
+	}
+	if first.Config.Host != "https://127.0.0.1:6443" {
+		t.Errorf("Expected the server of the kubeconfig, got %q", first.Config.Host)
+	}
+	if first.Config.QPS != 50 || first.Config.Burst != 100 || first.Config.UserAgent != "cert-exporter/test" {
+		t.Errorf("Expected the client options to be applied, got qps %v, burst %d, user agent %q", first.Config.QPS, first.Config.Burst, first.Config.UserAgent)
+	}
+
+	second, err := clients.Get(path)
+	if err != nil {
+		t.Fatalf("Failed to get cached client: %v", err)
+	}
+	if first != second {
+		t.Error("Expected checkers using the same kubeconfig to share a client")
+	}
+
+	if _, err := clients.Get(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing kubeconfig")
+	}
+}
@@ -0,0 +1,110 @@
+package kubeclient
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+)
+
+// informerResyncCheckPeriod is how often informers check whether a handler is due for a resync.  Handlers pick their
+// own resync period, which is raised to this period if they are added to an informer that is already running.
+const informerResyncCheckPeriod = time.Minute
+
+// Informers hands out SharedInformerFactories.  Every checker watching the same namespace with the same label and field
+// selectors gets the same factory, so the objects are listed and watched once and kept in a single cache.  The informers of a
+// factory run until the last checker using it releases it or the stop channel passed to NewInformers is closed.
+type Informers struct {
+	mu        sync.Mutex
+	client    kubernetes.Interface
+	stopCh    <-chan struct{}
+	factories map[informerKey]*sharedFactory
+}
+
+// sharedFactory is a factory with the number of checkers using it
+type sharedFactory struct {
+	factory informers.SharedInformerFactory
+	users   int
+	// release is closed once the last user released the factory, and stop once either release or the stop
+	// channel of the Informers is closed
+	release chan struct{}
+	stop    chan struct{}
+}
+
+type informerKey struct {
+	namespace     string
+	labelSelector string
+	fieldSelector string
+}
+
+// NewInformers is a factory method that returns a new Informers
+func NewInformers(client kubernetes.Interface, stopCh <-chan struct{}) *Informers {
+	return &Informers{
+		client:    client,
+		stopCh:    stopCh,
+		factories: map[informerKey]*sharedFactory{},
+	}
+}
+
+// Factory returns the factory for objects in a namespace, or in every namespace if it is empty, matching labelSelector
+// and fieldSelector, and the function releasing it once the caller no longer uses it.  The informers of the factory are
+// stopped once every caller released it.
+func (i *Informers) Factory(namespace, labelSelector, fieldSelector string) (informers.SharedInformerFactory, func()) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	key := informerKey{namespace: namespace, labelSelector: labelSelector, fieldSelector: fieldSelector}
+	shared, ok := i.factories[key]
+	if !ok {
+		shared = &sharedFactory{
+			factory: informers.NewSharedInformerFactoryWithOptions(i.client, informerResyncCheckPeriod,
+				informers.WithNamespace(namespace),
+				informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+					options.LabelSelector = labelSelector
+					options.FieldSelector = fieldSelector
+				})),
+			release: make(chan struct{}),
+			stop:    make(chan struct{}),
+		}
+		go func() {
+			select {
+			case <-i.stopCh:
+			case <-shared.release:
+			}
+			close(shared.stop)
+		}()
+		i.factories[key] = shared
+	}
+	shared.users++
+
+	var once sync.Once
+	return shared.factory, func() { once.Do(func() { i.release(key, shared) }) }
+}
+
+// release drops a user of a factory and stops its informers if it was the last one
+func (i *Informers) release(key informerKey, shared *sharedFactory) {
+	i.mu.Lock()
+	shared.users--
+	last := shared.users == 0
+	if last {
+		delete(i.factories, key)
+		close(shared.release)
+	}
+	i.mu.Unlock()
+
+	if last {
+		shared.factory.Shutdown()
+	}
+}
+
+// Start starts every informer requested from the factories that is not running yet
+func (i *Informers) Start() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, shared := range i.factories {
+		shared.factory.Start(shared.stop)
+	}
+}
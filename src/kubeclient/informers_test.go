@@ -0,0 +1,69 @@
+package kubeclient
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestInformers_Factory(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	informers := NewInformers(fake.NewSimpleClientset(), stopCh)
+
+	first, _ := informers.Factory("default", "app=web", "")
+	if shared, _ := informers.Factory("default", "app=web", ""); shared != first {
+		t.Error("Expected checkers watching the same objects to share a factory")
+	}
+	for _, key := range []informerKey{{"default", "", ""}, {"other", "app=web", ""}, {"default", "app=web", "type=kubernetes.io/tls"}} {
+		if factory, _ := informers.Factory(key.namespace, key.labelSelector, key.fieldSelector); factory == first {
+			t.Errorf("Expected a separate factory for %v", key)
+		}
+	}
+
+	informer := first.Core().V1().Secrets().Informer()
+	informers.Start()
+	synced := make(chan struct{})
+	time.AfterFunc(5*time.Second, func() { close(synced) })
+	if !cache.WaitForCacheSync(synced, informer.HasSynced) {
+		t.Error("Expected Start to run requested informers")
+	}
+}
+
+func TestInformers_Release(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	informers := NewInformers(fake.NewSimpleClientset(), stopCh)
+
+	// Two checkers share the factory, e.g. the old and the new one during a reload
+	first, releaseOld := informers.Factory("default", "", "")
+	_, releaseNew := informers.Factory("default", "", "")
+	informer := first.Core().V1().Secrets().Informer()
+	informers.Start()
+
+	releaseOld()
+	releaseOld()
+	if informer.IsStopped() {
+		t.Error("Expected the informer to keep running while a checker still uses the factory")
+	}
+	if factory, release := informers.Factory("default", "", ""); factory != first {
+		t.Error("Expected the factory to be kept while a checker still uses it")
+	} else {
+		release()
+	}
+
+	releaseNew()
+	if !informer.IsStopped() {
+		t.Error("Expected the informer to stop once the last checker released the factory")
+	}
+	if len(informers.factories) != 0 {
+		t.Errorf("Expected released factories to be forgotten, got %d", len(informers.factories))
+	}
+	if factory, _ := informers.Factory("default", "", ""); factory == first {
+		t.Error("Expected a new factory after the old one was released")
+	}
+}
@@ -0,0 +1,61 @@
+package kubeclient
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Permission is a request a checker needs to be allowed to make against the API server
+type Permission struct {
+	Verb     string
+	Group    string
+	Resource string
+	// Namespace is empty for cluster scoped resources and requests across all namespaces
+	Namespace string
+}
+
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+
+	if p.Namespace == "" {
+		return p.Verb + " " + resource
+	}
+	return fmt.Sprintf("%s %s in namespace %s", p.Verb, resource, p.Namespace)
+}
+
+// MissingPermissions asks the API server with a SelfSubjectAccessReview for each permission whether the client is
+// allowed to make the request and returns the permissions that are denied.  An error means the API server could not
+// be reached or refused the review.
+func MissingPermissions(ctx context.Context, client kubernetes.Interface, permissions []Permission) ([]Permission, error) {
+	var missing []Permission
+
+	for _, p := range permissions {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: p.Namespace,
+					Verb:      p.Verb,
+					Group:     p.Group,
+					Resource:  p.Resource,
+				},
+			},
+		}
+
+		result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("reviewing access to %s: %w", p, err)
+		}
+		if !result.Status.Allowed {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,51 @@
+package kubeclient
+
+import (
+	"context"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPermission_String(t *testing.T) {
+	tests := []struct {
+		permission Permission
+		want       string
+	}{
+		{Permission{Verb: "list", Resource: "secrets"}, "list secrets"},
+		{Permission{Verb: "get", Resource: "secrets", Namespace: "default"}, "get secrets in namespace default"},
+		{Permission{Verb: "list", Group: "cert-manager.io", Resource: "certificates"}, "list certificates.cert-manager.io"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.permission.String(); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestMissingPermissions(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Namespace != "forbidden"
+		return true, review, nil
+	})
+
+	permissions := []Permission{
+		{Verb: "list", Resource: "secrets", Namespace: "default"},
+		{Verb: "list", Resource: "secrets", Namespace: "forbidden"},
+		{Verb: "list", Resource: "namespaces"},
+	}
+
+	missing, err := MissingPermissions(context.Background(), client, permissions)
+	if err != nil {
+		t.Fatalf("Failed to review permissions: %v", err)
+	}
+	if len(missing) != 1 || missing[0] != permissions[1] {
+		t.Errorf("Expected only %v to be missing, got %v", permissions[1], missing)
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LabelsVec is a prometheus collector for info metrics that copy the labels and annotations of Kubernetes objects,
+// like kube-state-metrics' kube_*_labels.  Checkers may copy different labels, so it holds one gauge vector per set of
+// copied label names, all exported under the same name.
+type LabelsVec struct {
+	opts   prometheus.GaugeOpts
+	labels []string
+
+	mu   sync.Mutex
+	vecs map[string]*prometheus.GaugeVec
+}
+
+func newLabelsVec(opts prometheus.GaugeOpts, labels ...string) *LabelsVec {
+	return &LabelsVec{opts: opts, labels: labels, vecs: map[string]*prometheus.GaugeVec{}}
+}
+
+// With returns the gauge vector for objects with the copied label names, which follow the labels identifying the
+// object.  The same names always return the same vector.
+func (v *LabelsVec) With(names []string) *prometheus.GaugeVec {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := strings.Join(names, ",")
+	vec, ok := v.vecs[key]
+	if !ok {
+		vec = prometheus.NewGaugeVec(v.opts, append(append([]string{}, v.labels...), names...))
+		v.vecs[key] = vec
+	}
+	return vec
+}
+
+// Reset deletes every series
+func (v *LabelsVec) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, vec := range v.vecs {
+		vec.Reset()
+	}
+}
+
+// DeletePartialMatch deletes every series whose labels include labels and returns how many were deleted
+func (v *LabelsVec) DeletePartialMatch(labels prometheus.Labels) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	deleted := 0
+	for _, vec := range v.vecs {
+		deleted += vec.DeletePartialMatch(labels)
+	}
+	return deleted
+}
+
+// Describe sends nothing, which registers the LabelsVec as an unchecked collector as its label names are not known
+// up front.
+func (v *LabelsVec) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector
+func (v *LabelsVec) Collect(ch chan<- prometheus.Metric) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, vec := range v.vecs {
+		vec.Collect(ch)
+	}
+}
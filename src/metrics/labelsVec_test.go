@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestLabelsVec(t *testing.T) {
+	vec := newLabelsVec(prometheus.GaugeOpts{Namespace: namespace, Name: "test_labels", Help: "Test labels."}, "name")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(vec)
+
+	if vec.With([]string{"label_team"}) != vec.With([]string{"label_team"}) {
+		t.Error("Expected the same label names to return the same gauge vector")
+	}
+	vec.With([]string{"label_team"}).WithLabelValues("a", "payments").Set(1)
+	vec.With([]string{"label_app"}).WithLabelValues("b", "checkout").Set(1)
+
+	mfs, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(mfs) != 1 || len(mfs[0].GetMetric()) != 2 {
+		t.Fatalf("Expected one family with 2 series, got %v", mfs)
+	}
+
+	if deleted := vec.DeletePartialMatch(prometheus.Labels{"name": "a"}); deleted != 1 {
+		t.Errorf("Expected 1 series to be deleted, got %d", deleted)
+	}
+	vec.Reset()
+	mfs, err = registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(mfs) != 0 {
+		t.Errorf("Expected no series after Reset, got %v", mfs)
+	}
+}
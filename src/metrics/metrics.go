@@ -1,12 +1,25 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
-import versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
+	dto "github.com/prometheus/client_model/go"
+)
 
 const (
 	namespace = "cert_exporter"
 )
 
+// certInfoLabels are the labels describing a single certificate on every *_cert_info metric.
+var certInfoLabels = []string{"issuer", "cn", "fingerprint_sha256", "serial_number", "dns_names", "ip_addresses", "public_key_algorithm", "public_key_size", "signature_algorithm", "is_ca", "alias"}
+
+// withCertInfoLabels appends the certificate detail labels to the labels identifying where a cert was found.
+func withCertInfoLabels(labels ...string) []string {
+	return append(labels, certInfoLabels...)
+}
+
 var (
 	// ErrorTotal is a prometheus counter that indicates the total number of unexpected errors encountered by the application
 	ErrorTotal = prometheus.NewCounter(
@@ -17,6 +30,75 @@ var (
 		},
 	)
 
+	// ConfigLastReloadSuccess is a prometheus gauge that indicates whether the last attempt to load the configuration succeeded
+	ConfigLastReloadSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "config_last_reload_success",
+			Help:      "Whether the last configuration reload attempt was successful.",
+		},
+	)
+
+	// ConfigLastReloadSuccessTimestamp is a prometheus gauge that indicates when the configuration was last loaded successfully
+	ConfigLastReloadSuccessTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Timestamp of the last successful configuration reload.",
+		},
+	)
+
+	// ScanLastSuccessTimestamp is a prometheus gauge that indicates when a checker last finished a scan without errors
+	ScanLastSuccessTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_scan_success_timestamp",
+			Help:      "Timestamp of the last scan of the checker that finished without errors.",
+		},
+		[]string{"checker", "source"},
+	)
+
+	// ScanDurationSeconds is a prometheus histogram of how long the scans of a checker take
+	ScanDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "scan_duration_seconds",
+			Help:      "Duration of the scans of the checker.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+		},
+		[]string{"checker", "source"},
+	)
+
+	// ScanObjects is a prometheus gauge that indicates the number of objects the last scan of a checker looked at
+	ScanObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "objects_scanned",
+			Help:      "Number of files, secrets, configmaps or other objects looked at by the last scan of the checker.",
+		},
+		[]string{"checker", "source"},
+	)
+
+	// ScanCertsParsed is a prometheus gauge that indicates the number of certificates the last scan of a checker parsed
+	ScanCertsParsed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certs_parsed",
+			Help:      "Number of certificates parsed by the last scan of the checker.",
+		},
+		[]string{"checker", "source"},
+	)
+
+	// ScanErrorsTotal is a prometheus counter of the errors a checker ran into, by reason
+	ScanErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Errors encountered by the checker.",
+		},
+		[]string{"checker", "source", "reason"},
+	)
+
 	// Discovered is a prometheus guage that indicates the sum of discovered certificates after taking into account include and exclude globs
 	Discovered = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -36,6 +118,16 @@ var (
 		[]string{"filename", "issuer", "cn", "nodename"},
 	)
 
+	// CertValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of certificates on disk that is left
+	CertValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cert_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"filename", "issuer", "cn", "nodename"},
+	)
+
 	// CertNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
 	CertNotAfterTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -66,6 +158,16 @@ var (
 		[]string{"filename", "type", "cn", "issuer", "name", "nodename"},
 	)
 
+	// KubeConfigValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of kubeconfig certificates that is left
+	KubeConfigValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "kubeconfig_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert in the kubeconfig that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"filename", "type", "cn", "issuer", "name", "nodename"},
+	)
+
 	// KubeConfigNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
 	KubeConfigNotAfterTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -96,6 +198,16 @@ var (
 		[]string{"key_name", "issuer", "cn", "secret_name", "secret_namespace"},
 	)
 
+	// SecretValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of kubernetes secret certificates that is left
+	SecretValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "secret_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert in the secret that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"key_name", "issuer", "cn", "secret_name", "secret_namespace"},
+	)
+
 	// SecretNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
 	SecretNotAfterTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -126,6 +238,16 @@ var (
 		[]string{"issuer", "cn", "cert_request", "certrequest_namespace"},
 	)
 
+	// CertRequestValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of certificates in cert-manager certificate requests that is left
+	CertRequestValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certrequest_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert in the certrequest that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"issuer", "cn", "cert_request", "certrequest_namespace"},
+	)
+
 	// CertRequestNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
 	CertRequestNotAfterTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -146,6 +268,96 @@ var (
 		[]string{"issuer", "cn", "cert_request", "certrequest_namespace"},
 	)
 
+	// CertificateExpirySeconds is a prometheus gauge that indicates the number of seconds until the certificate of a cert-manager Certificate expires, based on status.notAfter.
+	CertificateExpirySeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_expires_in_seconds",
+			Help:      "Number of seconds til the cert-manager certificate expires.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of a cert-manager Certificate that is left, based on status.notBefore and status.notAfter.
+	CertificateValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert-manager certificate that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateNotAfterTimestamp is a prometheus gauge that indicates the status.notAfter timestamp of a cert-manager Certificate.
+	CertificateNotAfterTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_not_after_timestamp",
+			Help:      "Expiration timestamp of the cert-manager certificate.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateRenewalSeconds is a prometheus gauge that indicates the number of seconds until cert-manager renews a Certificate. It is negative once status.renewalTime has passed.
+	CertificateRenewalSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_renewal_in_seconds",
+			Help:      "Number of seconds til cert-manager renews the certificate, negative if the renewal time has passed.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateRenewalTimestamp is a prometheus gauge that indicates the status.renewalTime timestamp of a cert-manager Certificate.
+	CertificateRenewalTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_renewal_timestamp",
+			Help:      "Timestamp at which cert-manager renews the certificate.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateRenewalOverdue is a prometheus gauge that indicates whether the renewal time of a cert-manager Certificate has passed without a new cert being issued.
+	CertificateRenewalOverdue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_renewal_overdue",
+			Help:      "Whether the renewal time of the cert-manager certificate has passed without a new cert being issued.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateReady is a prometheus gauge that indicates whether the Ready condition of a cert-manager Certificate is True.
+	CertificateReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_ready",
+			Help:      "Whether the cert-manager certificate is ready.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateIssuing is a prometheus gauge that indicates whether the Issuing condition of a cert-manager Certificate is True.
+	CertificateIssuing = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_issuing",
+			Help:      "Whether cert-manager is issuing a new cert for the certificate.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
+	// CertificateFailedIssuanceAttempts is a prometheus gauge that indicates the number of consecutive failed issuance attempts of a cert-manager Certificate.
+	CertificateFailedIssuanceAttempts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certificate_failed_issuance_attempts",
+			Help:      "Number of consecutive failed issuance attempts of the cert-manager certificate.",
+		},
+		[]string{"certificate", "certificate_namespace", "secret_name"},
+	)
+
 	// AwsCertExpirySeconds is a prometheus gauge that indicates the number of seconds until certificates on AWS expires.
 	AwsCertExpirySeconds = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -156,6 +368,16 @@ var (
 		[]string{"secretName", "key", "file", "issuer", "cn"},
 	)
 
+	// AwsCertValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of certificates on AWS that is left
+	AwsCertValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cert_validity_remaining_ratio_aws",
+			Help:      "Fraction of the validity period of the cert that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"secretName", "key", "file", "issuer", "cn"},
+	)
+
 	// ConfigMapExpirySeconds is a prometheus gauge that indicates the number of seconds until a kubernetes configmap certificate expires
 	ConfigMapExpirySeconds = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -166,6 +388,16 @@ var (
 		[]string{"key_name", "issuer", "cn", "configmap_name", "configmap_namespace"},
 	)
 
+	// ConfigMapValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of kubernetes configmap certificates that is left
+	ConfigMapValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "configmap_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert in the configmap that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"key_name", "issuer", "cn", "configmap_name", "configmap_namespace"},
+	)
+
 	// ConfigMapNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
 	ConfigMapNotAfterTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -196,6 +428,16 @@ var (
 		[]string{"type_name", "issuer", "cn", "webhook_name", "admission_review_version_name"},
 	)
 
+	// WebhookValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of kubernetes webhook certificates that is left
+	WebhookValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "webhook_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert in the webhook that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"type_name", "issuer", "cn", "webhook_name", "admission_review_version_name"},
+	)
+
 	// WebhookNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
 	WebhookNotAfterTimestamp = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -216,6 +458,326 @@ var (
 		[]string{"type_name", "issuer", "cn", "webhook_name", "admission_review_version_name"},
 	)
 
+	// CertInfo is a prometheus gauge that describes certificates on disk. It is always 1.
+	CertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cert_info",
+			Help:      "Details of the cert, always 1.",
+		},
+		withCertInfoLabels("filename", "nodename"),
+	)
+
+	// KubeConfigCertInfo is a prometheus gauge that describes kubeconfig certificates. It is always 1.
+	KubeConfigCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "kubeconfig_cert_info",
+			Help:      "Details of the cert in the kubeconfig, always 1.",
+		},
+		withCertInfoLabels("filename", "type", "name", "nodename"),
+	)
+
+	// SecretCertInfo is a prometheus gauge that describes kubernetes secret certificates. It is always 1.
+	SecretCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "secret_cert_info",
+			Help:      "Details of the cert in the secret, always 1.",
+		},
+		withCertInfoLabels("key_name", "secret_name", "secret_namespace"),
+	)
+
+	// CertRequestCertInfo is a prometheus gauge that describes cert-manager certificate request certificates. It is always 1.
+	CertRequestCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certrequest_cert_info",
+			Help:      "Details of the cert in the certrequest, always 1.",
+		},
+		withCertInfoLabels("cert_request", "certrequest_namespace"),
+	)
+
+	// AwsCertInfo is a prometheus gauge that describes certificates on AWS. It is always 1.
+	AwsCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cert_info_aws",
+			Help:      "Details of the cert, always 1.",
+		},
+		withCertInfoLabels("secretName", "key"),
+	)
+
+	// ConfigMapCertInfo is a prometheus gauge that describes kubernetes configmap certificates. It is always 1.
+	ConfigMapCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "configmap_cert_info",
+			Help:      "Details of the cert in the configmap, always 1.",
+		},
+		withCertInfoLabels("key_name", "configmap_name", "configmap_namespace"),
+	)
+
+	// WebhookCertInfo is a prometheus gauge that describes kubernetes webhook certificates. It is always 1.
+	WebhookCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "webhook_cert_info",
+			Help:      "Details of the cert in the webhook, always 1.",
+		},
+		withCertInfoLabels("type_name", "webhook_name", "admission_review_version_name"),
+	)
+
+	// CertStatus is a prometheus gauge that indicates how severe the remaining validity of a cert is, one series per severity.
+	CertStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "cert_status",
+			Help:      "1 for the severity of the remaining validity of the cert compared to its warn-before and critical-before thresholds, 0 for the others.",
+		},
+		[]string{"source", "namespace", "name", "key_name", "issuer", "cn", "severity"},
+	)
+
+	// PasswordFailed is a prometheus gauge that flags PKCS12 bundles and Java keystores that could not be read because none of their passwords matched. It is always 1.
+	PasswordFailed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "password_failed",
+			Help:      "1 if the PKCS12 bundle or Java keystore could not be read because none of the configured passwords matched.",
+		},
+		[]string{"source", "namespace", "name", "key_name", "reason"},
+	)
+
+	// SecretKeypairMatch is a prometheus gauge that checks the private key of kubernetes.io/tls secrets against their cert.
+	SecretKeypairMatch = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "secret_keypair_match",
+			Help:      "1 if the private key of the kubernetes.io/tls secret belongs to its cert, 0 otherwise. The reason label tells why.",
+		},
+		[]string{"secret_name", "secret_namespace", "reason"},
+	)
+
+	// SecretChainValid is a prometheus gauge that verifies the cert chain of kubernetes.io/tls secrets against the configured roots.
+	SecretChainValid = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "secret_chain_valid",
+			Help:      "1 if the cert chain of the kubernetes.io/tls secret verifies against the roots and is in order, 0 otherwise. The reason label tells why.",
+		},
+		[]string{"key_name", "secret_name", "secret_namespace", "reason"},
+	)
+
+	// SecretChainLength is a prometheus gauge that counts the certs on the verified path of kubernetes.io/tls secrets.
+	SecretChainLength = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "secret_chain_length",
+			Help:      "Number of certs from the leaf to the root on the verified path of the kubernetes.io/tls secret.",
+		},
+		[]string{"key_name", "secret_name", "secret_namespace"},
+	)
+
+	// SecretChainExpirySeconds is a prometheus gauge that indicates the earliest expiry on the verified path of kubernetes.io/tls secrets.
+	SecretChainExpirySeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "secret_chain_expires_in_seconds",
+			Help:      "Number of seconds til the first cert on the verified path of the kubernetes.io/tls secret expires.",
+		},
+		[]string{"key_name", "secret_name", "secret_namespace"},
+	)
+
+	// SecretLabels is a prometheus gauge that copies the labels and annotations of kubernetes secrets. It is always 1.
+	SecretLabels = newLabelsVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "secret_labels",
+			Help:      "Labels and annotations of the secret copied with --secrets-label-to-metric, always 1.",
+		},
+		"secret_name", "secret_namespace",
+	)
+
+	// ConfigMapLabels is a prometheus gauge that copies the labels and annotations of kubernetes configmaps. It is always 1.
+	ConfigMapLabels = newLabelsVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "configmap_labels",
+			Help:      "Labels and annotations of the configmap copied with --configmaps-label-to-metric, always 1.",
+		},
+		"configmap_name", "configmap_namespace",
+	)
+
+	// CertRequestLabels is a prometheus gauge that copies the labels and annotations of cert-manager certificate requests. It is always 1.
+	CertRequestLabels = newLabelsVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "certrequest_labels",
+			Help:      "Labels and annotations of the certrequest copied with --certrequests-label-to-metric, always 1.",
+		},
+		"cert_request", "certrequest_namespace",
+	)
+
+	// WebhookLabels is a prometheus gauge that copies the labels and annotations of kubernetes webhook configurations. It is always 1.
+	WebhookLabels = newLabelsVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "webhook_labels",
+			Help:      "Labels and annotations of the webhook configuration copied with --webhooks-label-to-metric, always 1.",
+		},
+		"type_name", "webhook_name",
+	)
+
+	// EndpointExpirySeconds is a prometheus gauge that indicates the number of seconds until a certificate served by a TLS endpoint expires.
+	EndpointExpirySeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "endpoint_expires_in_seconds",
+			Help:      "Number of seconds til the cert served by the endpoint expires.",
+		},
+		[]string{"endpoint", "server_name", "issuer", "cn"},
+	)
+
+	// EndpointValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of certificates served by TLS endpoints that is left
+	EndpointValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "endpoint_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert served by the endpoint that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"endpoint", "server_name", "issuer", "cn"},
+	)
+
+	// EndpointNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
+	EndpointNotAfterTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "endpoint_not_after_timestamp",
+			Help:      "Expiration timestamp for cert served by the endpoint.",
+		},
+		[]string{"endpoint", "server_name", "issuer", "cn"},
+	)
+
+	// EndpointNotBeforeTimestamp is a prometheus gauge that indicates the NotBefore timestamp.
+	EndpointNotBeforeTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "endpoint_not_before_timestamp",
+			Help:      "Activation timestamp for cert served by the endpoint.",
+		},
+		[]string{"endpoint", "server_name", "issuer", "cn"},
+	)
+
+	// EndpointCertInfo is a prometheus gauge that describes certificates served by TLS endpoints. It is always 1.
+	EndpointCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "endpoint_cert_info",
+			Help:      "Details of the cert served by the endpoint, always 1.",
+		},
+		withCertInfoLabels("endpoint", "server_name"),
+	)
+
+	// EndpointProbeSuccess is a prometheus gauge that indicates whether the last TLS handshake with an endpoint succeeded.
+	EndpointProbeSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "endpoint_probe_success",
+			Help:      "Whether the last TLS probe of the endpoint succeeded.",
+		},
+		[]string{"endpoint", "server_name"},
+	)
+
+	// EndpointHandshakeDurationSeconds is a prometheus gauge that indicates how long the last TLS handshake with an endpoint took.
+	EndpointHandshakeDurationSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "endpoint_handshake_duration_seconds",
+			Help:      "Duration of the last successful TLS handshake with the endpoint.",
+		},
+		[]string{"endpoint", "server_name"},
+	)
+
+	// IngressExpirySeconds is a prometheus gauge that indicates the number of seconds until a certificate used by an ingress TLS host expires.
+	IngressExpirySeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_expires_in_seconds",
+			Help:      "Number of seconds til the cert for the ingress host expires.",
+		},
+		[]string{"ingress_name", "ingress_namespace", "host", "secret_name", "source", "issuer", "cn"},
+	)
+
+	// IngressValidityRemainingRatio is a prometheus gauge that indicates the fraction of the lifetime of certificates used by ingress TLS hosts that is left
+	IngressValidityRemainingRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_validity_remaining_ratio",
+			Help:      "Fraction of the validity period of the cert for the ingress host that is left, from 1 at notBefore to 0 at notAfter.",
+		},
+		[]string{"ingress_name", "ingress_namespace", "host", "secret_name", "source", "issuer", "cn"},
+	)
+
+	// IngressNotAfterTimestamp is a prometheus gauge that indicates the NotAfter timestamp.
+	IngressNotAfterTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_not_after_timestamp",
+			Help:      "Expiration timestamp for cert for the ingress host.",
+		},
+		[]string{"ingress_name", "ingress_namespace", "host", "secret_name", "source", "issuer", "cn"},
+	)
+
+	// IngressNotBeforeTimestamp is a prometheus gauge that indicates the NotBefore timestamp.
+	IngressNotBeforeTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_not_before_timestamp",
+			Help:      "Activation timestamp for cert for the ingress host.",
+		},
+		[]string{"ingress_name", "ingress_namespace", "host", "secret_name", "source", "issuer", "cn"},
+	)
+
+	// IngressCertInfo is a prometheus gauge that describes certificates used by ingress TLS hosts. It is always 1.
+	IngressCertInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_cert_info",
+			Help:      "Details of the cert for the ingress host, always 1.",
+		},
+		withCertInfoLabels("ingress_name", "ingress_namespace", "host", "secret_name", "source"),
+	)
+
+	// IngressTLSSecretMissing is a prometheus gauge that indicates whether the secret referenced by an ingress TLS entry is missing.
+	IngressTLSSecretMissing = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_tls_secret_missing",
+			Help:      "Whether the secret referenced by the ingress TLS entry does not exist.",
+		},
+		[]string{"ingress_name", "ingress_namespace", "secret_name"},
+	)
+
+	// IngressTLSFingerprintMismatch is a prometheus gauge that indicates whether the cert served for an ingress host differs from the referenced secret.
+	IngressTLSFingerprintMismatch = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_tls_fingerprint_mismatch",
+			Help:      "Whether the cert served for the ingress host differs from the cert in the referenced secret.",
+		},
+		[]string{"ingress_name", "ingress_namespace", "host", "secret_name"},
+	)
+
+	// IngressProbeSuccess is a prometheus gauge that indicates whether the last TLS probe of an ingress host through the ingress controller succeeded.
+	IngressProbeSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ingress_probe_success",
+			Help:      "Whether the last TLS probe of the ingress host through the ingress controller succeeded.",
+		},
+		[]string{"ingress_name", "ingress_namespace", "host"},
+	)
+
 	// BuildInfo is a prometheus gauge that shows build information about the cert-exporter 
 	BuildInfo = versioncollector.NewCollector("cert_exporter")
 )
@@ -236,24 +798,99 @@ func Init(prometheusExporterMetricsDisabled bool, registry *prometheus.Registry)
 
 	registerer.MustRegister(Discovered)
 	registerer.MustRegister(ErrorTotal)
+	registerer.MustRegister(ConfigLastReloadSuccess)
+	registerer.MustRegister(ConfigLastReloadSuccessTimestamp)
+	registerer.MustRegister(ScanLastSuccessTimestamp)
+	registerer.MustRegister(ScanDurationSeconds)
+	registerer.MustRegister(ScanObjects)
+	registerer.MustRegister(ScanCertsParsed)
+	registerer.MustRegister(ScanErrorsTotal)
 	registerer.MustRegister(CertExpirySeconds)
+	registerer.MustRegister(CertValidityRemainingRatio)
 	registerer.MustRegister(CertNotAfterTimestamp)
 	registerer.MustRegister(CertNotBeforeTimestamp)
 	registerer.MustRegister(KubeConfigExpirySeconds)
+	registerer.MustRegister(KubeConfigValidityRemainingRatio)
 	registerer.MustRegister(KubeConfigNotAfterTimestamp)
 	registerer.MustRegister(KubeConfigNotBeforeTimestamp)
 	registerer.MustRegister(SecretExpirySeconds)
+	registerer.MustRegister(SecretValidityRemainingRatio)
 	registerer.MustRegister(SecretNotAfterTimestamp)
 	registerer.MustRegister(SecretNotBeforeTimestamp)
 	registerer.MustRegister(CertRequestExpirySeconds)
+	registerer.MustRegister(CertRequestValidityRemainingRatio)
 	registerer.MustRegister(CertRequestNotAfterTimestamp)
 	registerer.MustRegister(CertRequestNotBeforeTimestamp)
 	registerer.MustRegister(ConfigMapExpirySeconds)
+	registerer.MustRegister(ConfigMapValidityRemainingRatio)
 	registerer.MustRegister(ConfigMapNotAfterTimestamp)
 	registerer.MustRegister(ConfigMapNotBeforeTimestamp)
 	registerer.MustRegister(WebhookExpirySeconds)
+	registerer.MustRegister(WebhookValidityRemainingRatio)
 	registerer.MustRegister(WebhookNotAfterTimestamp)
 	registerer.MustRegister(WebhookNotBeforeTimestamp)
 	registerer.MustRegister(AwsCertExpirySeconds)
+	registerer.MustRegister(AwsCertValidityRemainingRatio)
+	registerer.MustRegister(CertInfo)
+	registerer.MustRegister(KubeConfigCertInfo)
+	registerer.MustRegister(SecretCertInfo)
+	registerer.MustRegister(CertRequestCertInfo)
+	registerer.MustRegister(AwsCertInfo)
+	registerer.MustRegister(ConfigMapCertInfo)
+	registerer.MustRegister(WebhookCertInfo)
+	registerer.MustRegister(CertStatus)
+	registerer.MustRegister(PasswordFailed)
+	registerer.MustRegister(SecretKeypairMatch)
+	registerer.MustRegister(SecretChainValid)
+	registerer.MustRegister(SecretChainLength)
+	registerer.MustRegister(SecretChainExpirySeconds)
+	registerer.MustRegister(SecretLabels)
+	registerer.MustRegister(ConfigMapLabels)
+	registerer.MustRegister(CertRequestLabels)
+	registerer.MustRegister(WebhookLabels)
+	registerer.MustRegister(EndpointExpirySeconds)
+	registerer.MustRegister(EndpointValidityRemainingRatio)
+	registerer.MustRegister(EndpointNotAfterTimestamp)
+	registerer.MustRegister(EndpointNotBeforeTimestamp)
+	registerer.MustRegister(EndpointCertInfo)
+	registerer.MustRegister(EndpointProbeSuccess)
+	registerer.MustRegister(EndpointHandshakeDurationSeconds)
+	registerer.MustRegister(IngressExpirySeconds)
+	registerer.MustRegister(IngressValidityRemainingRatio)
+	registerer.MustRegister(IngressNotAfterTimestamp)
+	registerer.MustRegister(IngressNotBeforeTimestamp)
+	registerer.MustRegister(IngressCertInfo)
+	registerer.MustRegister(IngressTLSSecretMissing)
+	registerer.MustRegister(IngressTLSFingerprintMismatch)
+	registerer.MustRegister(IngressProbeSuccess)
+	registerer.MustRegister(CertificateExpirySeconds)
+	registerer.MustRegister(CertificateValidityRemainingRatio)
+	registerer.MustRegister(CertificateNotAfterTimestamp)
+	registerer.MustRegister(CertificateRenewalSeconds)
+	registerer.MustRegister(CertificateRenewalTimestamp)
+	registerer.MustRegister(CertificateRenewalOverdue)
+	registerer.MustRegister(CertificateReady)
+	registerer.MustRegister(CertificateIssuing)
+	registerer.MustRegister(CertificateFailedIssuanceAttempts)
 	registerer.MustRegister(BuildInfo)
 }
+
+// publishMu keeps a gather from observing a scan result that is only partially published.
+var publishMu sync.RWMutex
+
+// Publish runs fn while gathers through a gatherer returned by NewGatherer are blocked, so every
+// series change made by fn becomes visible to scrapes at once.
+func Publish(fn func()) {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+	fn()
+}
+
+// NewGatherer wraps g so that it never gathers while a scan result is being published.
+func NewGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		publishMu.RLock()
+		defer publishMu.RUnlock()
+		return g.Gather()
+	})
+}
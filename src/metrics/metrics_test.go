@@ -88,6 +88,13 @@ func TestMetricsDefinitions(t *testing.T) {
 		"BuildInfo":                       BuildInfo,
         "Discovered":                      Discovered,
     	"ErrorTotal":                      ErrorTotal,
+		"ConfigLastReloadSuccess":          ConfigLastReloadSuccess,
+		"ConfigLastReloadSuccessTimestamp": ConfigLastReloadSuccessTimestamp,
+		"ScanLastSuccessTimestamp":         ScanLastSuccessTimestamp,
+		"ScanDurationSeconds":              ScanDurationSeconds,
+		"ScanObjects":                      ScanObjects,
+		"ScanCertsParsed":                  ScanCertsParsed,
+		"ScanErrorsTotal":                  ScanErrorsTotal,
 		"CertExpirySeconds":               CertExpirySeconds,
 		"CertNotAfterTimestamp":           CertNotAfterTimestamp,
 		"CertNotBeforeTimestamp":          CertNotBeforeTimestamp,
@@ -107,6 +114,34 @@ func TestMetricsDefinitions(t *testing.T) {
 		"WebhookExpirySeconds":            WebhookExpirySeconds,
 		"WebhookNotAfterTimestamp":        WebhookNotAfterTimestamp,
 		"WebhookNotBeforeTimestamp":       WebhookNotBeforeTimestamp,
+		"CertInfo":                        CertInfo,
+		"KubeConfigCertInfo":              KubeConfigCertInfo,
+		"SecretCertInfo":                  SecretCertInfo,
+		"CertRequestCertInfo":             CertRequestCertInfo,
+		"AwsCertInfo":                     AwsCertInfo,
+		"ConfigMapCertInfo":               ConfigMapCertInfo,
+		"WebhookCertInfo":                 WebhookCertInfo,
+		"EndpointExpirySeconds":           EndpointExpirySeconds,
+		"EndpointNotAfterTimestamp":       EndpointNotAfterTimestamp,
+		"EndpointNotBeforeTimestamp":      EndpointNotBeforeTimestamp,
+		"EndpointCertInfo":                EndpointCertInfo,
+		"EndpointProbeSuccess":            EndpointProbeSuccess,
+		"EndpointHandshakeDurationSeconds": EndpointHandshakeDurationSeconds,
+		"IngressExpirySeconds":            IngressExpirySeconds,
+		"IngressNotAfterTimestamp":        IngressNotAfterTimestamp,
+		"IngressNotBeforeTimestamp":       IngressNotBeforeTimestamp,
+		"IngressCertInfo":                 IngressCertInfo,
+		"IngressTLSSecretMissing":         IngressTLSSecretMissing,
+		"IngressTLSFingerprintMismatch":   IngressTLSFingerprintMismatch,
+		"IngressProbeSuccess":             IngressProbeSuccess,
+		"CertificateExpirySeconds":          CertificateExpirySeconds,
+		"CertificateNotAfterTimestamp":      CertificateNotAfterTimestamp,
+		"CertificateRenewalSeconds":         CertificateRenewalSeconds,
+		"CertificateRenewalTimestamp":       CertificateRenewalTimestamp,
+		"CertificateRenewalOverdue":         CertificateRenewalOverdue,
+		"CertificateReady":                  CertificateReady,
+		"CertificateIssuing":                CertificateIssuing,
+		"CertificateFailedIssuanceAttempts": CertificateFailedIssuanceAttempts,
   }
 
 	for name, metric := range metrics {
@@ -479,3 +514,27 @@ func TestDiscoveredGauge(t *testing.T) {
 	Discovered.Add(5)
 	Discovered.Sub(3)
 }
+
+func TestNewGatherer_WaitsForPublish(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "publish_test"})
+	testRegistry.MustRegister(gauge)
+	gatherer := NewGatherer(testRegistry)
+
+	gathered := make(chan float64)
+	Publish(func() {
+		go func() {
+			mfs, err := gatherer.Gather()
+			if err != nil {
+				t.Errorf("Failed to gather metrics: %v", err)
+			}
+			gathered <- mfs[0].GetMetric()[0].GetGauge().GetValue()
+		}()
+		gauge.Set(1)
+		gauge.Set(2)
+	})
+
+	if value := <-gathered; value != 2 {
+		t.Errorf("Expected gather to observe the published value 2, got %v", value)
+	}
+}
@@ -0,0 +1,399 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joe-elliott/cert-exporter/src/checkers"
+	"github.com/joe-elliott/cert-exporter/src/config"
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+// Runner runs the checkers of a config.  When a new config is applied only the checkers whose settings changed are
+// restarted, every other checker keeps running and keeps its metrics.
+type Runner struct {
+	mu       sync.Mutex
+	nodeName string
+	clients  *kubeclient.Clients
+	degrade  bool
+	running  map[string]*instance
+	stopped  bool
+}
+
+// stallPeriods is the number of polling periods a checker may go without finishing a scan before it is considered
+// stalled.  Scans are cut off after one period, so a healthy checker finishes one at least every other period.
+const stallPeriods = 3
+
+// validateTimeout bounds the permission checks of the checkers started by Apply
+const validateTimeout = 30 * time.Second
+
+// instance is a running checker
+type instance struct {
+	checker
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// checker is a checker described by a config, identified by its name
+type checker struct {
+	name   string
+	config any
+	health *exporters.ScanHealth
+	// period is how often the checker scans, 0 if it does not scan periodically
+	period time.Duration
+	run    func(ctx context.Context)
+
+	// client is the Kubernetes client used by the checker and permissions the requests it makes, both unset if the
+	// checker does not talk to the API server
+	client      *kubeclient.Client
+	permissions []kubeclient.Permission
+	// err is set if the checker could not be created
+	err error
+}
+
+// scanExporter is an exporter that reports the scans of its checker
+type scanExporter interface {
+	SetScanHealth(h *exporters.ScanHealth)
+	Clear()
+}
+
+// New is a factory method that returns a new Runner.  nodeName is passed to the checkers of certs and kubeconfigs on
+// disk and clients provides the Kubernetes clients shared by the other checkers.  If degrade is set, checkers whose
+// client is unusable or lacks permissions are left out instead of failing Apply.
+func New(nodeName string, clients *kubeclient.Clients, degrade bool) *Runner {
+	return &Runner{
+		nodeName: nodeName,
+		clients:  clients,
+		degrade:  degrade,
+		running:  map[string]*instance{},
+	}
+}
+
+// Apply starts the checkers of a validated config.  Checkers that are no longer configured or whose settings changed
+// are stopped and their metrics removed before their replacements start.  Before anything is changed the checkers to
+// be started are validated: their Kubernetes client must be usable and allowed to make every request the checker
+// needs.  If that fails for any checker Apply returns an error listing the problems and leaves the running checkers
+// alone, unless the Runner degrades, in which case only the affected checkers are not started.  Apply does nothing
+// once the Runner was stopped.
+func (r *Runner) Apply(ctx context.Context, cfg *config.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return nil
+	}
+
+	wanted := map[string]checker{}
+	var starting []checker
+	for _, c := range r.checkers(cfg) {
+		wanted[c.name] = c
+		if inst, ok := r.running[c.name]; !ok || !reflect.DeepEqual(c.config, inst.config) {
+			starting = append(starting, c)
+		}
+	}
+	sort.Slice(starting, func(i, j int) bool { return starting[i].name < starting[j].name })
+
+	validateCtx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+
+	unusable := map[string]bool{}
+	var errs []error
+	for _, c := range starting {
+		if err := c.validate(validateCtx); err != nil {
+			unusable[c.name] = true
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	if len(errs) > 0 && !r.degrade {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.Error("Not starting checker", "error", err)
+		metrics.ErrorTotal.Inc()
+	}
+
+	for name, inst := range r.running {
+		if c, ok := wanted[name]; ok && reflect.DeepEqual(c.config, inst.config) {
+			continue
+		}
+		slog.Info("Stopping checker", "name", name)
+		inst.stop()
+		delete(r.running, name)
+	}
+
+	for _, c := range starting {
+		if unusable[c.name] {
+			continue
+		}
+		slog.Info("Starting checker", "name", c.name)
+		r.running[c.name] = start(c)
+	}
+
+	return nil
+}
+
+// Ready returns an error unless every checker has finished its first scan
+func (r *Runner) Ready() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for name, inst := range r.running {
+		if !inst.health.Scanned() {
+			errs = append(errs, fmt.Errorf("%s: first scan not finished", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Live returns an error if a checker returned on its own or has not finished a scan for several polling periods
+func (r *Runner) Live() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for name, inst := range r.running {
+		select {
+		case <-inst.done:
+			errs = append(errs, fmt.Errorf("%s: checker stopped", name))
+			continue
+		default:
+		}
+
+		if inst.period > 0 {
+			if since := inst.health.SinceLastScan(); since > stallPeriods*inst.period {
+				errs = append(errs, fmt.Errorf("%s: no scan finished in %v", name, since.Round(time.Second)))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stop stops every checker and waits for them to return
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped = true
+
+	for name, inst := range r.running {
+		inst.stop()
+		delete(r.running, name)
+	}
+}
+
+func start(c checker) *instance {
+	ctx, cancel := context.WithCancel(context.Background())
+	inst := &instance{
+		checker: c,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(inst.done)
+		c.run(ctx)
+	}()
+
+	return inst
+}
+
+// stop cancels the checker and waits until it has removed its metrics
+func (i *instance) stop() {
+	i.cancel()
+	<-i.done
+}
+
+// runUntilDone returns a run function that runs startChecking until its context is cancelled and then removes the
+// series published through the exporter
+func runUntilDone(startChecking func(ctx context.Context), e interface{ Clear() }) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		startChecking(ctx)
+		e.Clear()
+	}
+}
+
+// validate checks that the client of a checker could be built and is allowed to make every request the checker needs
+func (c checker) validate(ctx context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.client == nil {
+		return nil
+	}
+
+	missing, err := kubeclient.MissingPermissions(ctx, c.client.Kubernetes, c.permissions)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		names := make([]string, len(missing))
+		for i, p := range missing {
+			names[i] = p.String()
+		}
+		return fmt.Errorf("missing permissions: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// withClient records the Kubernetes client of a checker and the requests the checker makes with it
+func (c checker) withClient(client *kubeclient.Client, permissions []kubeclient.Permission) checker {
+	c.client = client
+	c.permissions = permissions
+	return c
+}
+
+// newChecker describes a checker whose scans are reported through e.  source is the section of the config the
+// checker comes from.
+func newChecker(name string, cfg any, source string, period time.Duration, e scanExporter, startChecking func(ctx context.Context)) checker {
+	health := exporters.NewScanHealth(name, source)
+	e.SetScanHealth(health)
+
+	return checker{
+		name:   name,
+		config: cfg,
+		health: health,
+		period: period,
+		run:    runUntilDone(startChecking, e),
+	}
+}
+
+// checkers creates a checker for every entry of the config
+func (r *Runner) checkers(cfg *config.Config) []checker {
+	var result []checker
+
+	for _, c := range cfg.Certs {
+		e := &exporters.CertExporter{PasswordSources: passwordSources(c.PasswordSources, c.PasswordFile)}
+		certChecker := checkers.NewCertChecker(c.PollingPeriod, c.IncludeGlobs, c.ExcludeGlobs, r.nodeName, e)
+		result = append(result, newChecker(c.Name, c, "certs", c.PollingPeriod, e, certChecker.StartChecking))
+	}
+
+	for _, c := range cfg.KubeConfigs {
+		e := &exporters.KubeConfigExporter{}
+		configChecker := checkers.NewCertChecker(c.PollingPeriod, c.IncludeGlobs, c.ExcludeGlobs, r.nodeName, e)
+		result = append(result, newChecker(c.Name, c, "kubeconfigs", c.PollingPeriod, e, configChecker.StartChecking))
+	}
+
+	for _, c := range cfg.Secrets {
+		client, err := r.clients.Get(c.Kubeconfig)
+		if err != nil {
+			result = append(result, checker{name: c.Name, config: c, err: err})
+			continue
+		}
+
+		e := &exporters.SecretExporter{}
+		secretChecker := checkers.NewSecretChecker(c.PollingPeriod, c.LabelSelectors, c.IncludeGlobs, c.ExcludeGlobs, c.AnnotationSelectors, c.LabelsToMetric, c.Namespaces, c.NamespaceLabelSelectors, c.ExcludeNamespaces, client.Kubernetes, client.Informers, e, c.IncludeTypes, c.ListPageSize, c.ScanConcurrency, c.PasswordKey, passwordSources(c.PasswordSources, ""), c.CheckKeypair, c.VerifyChain, checkers.ChainRoots{File: c.ChainRootsFile, ConfigMap: c.ChainRootsConfigMap}, expiryThresholds(c.Thresholds))
+		if c.Watch {
+			result = append(result, newChecker(c.Name, c, "secrets", 0, e, secretChecker.StartWatching).withClient(client, secretChecker.Permissions(true)))
+		} else {
+			result = append(result, newChecker(c.Name, c, "secrets", c.PollingPeriod, e, secretChecker.StartChecking).withClient(client, secretChecker.Permissions(false)))
+		}
+	}
+
+	for _, c := range cfg.CertRequests {
+		client, err := r.clients.Get(c.Kubeconfig)
+		if err != nil {
+			result = append(result, checker{name: c.Name, config: c, err: err})
+			continue
+		}
+
+		e := &exporters.CertRequestExporter{}
+		certRequestChecker := checkers.NewCertRequestChecker(c.PollingPeriod, c.LabelSelectors, c.AnnotationSelectors, c.LabelsToMetric, c.Namespaces, c.ExcludeNamespaces, client.CertManager.CertmanagerV1(), client.Kubernetes, e, expiryThresholds(c.Thresholds))
+		result = append(result, newChecker(c.Name, c, "certrequests", c.PollingPeriod, e, certRequestChecker.StartChecking).withClient(client, certRequestChecker.Permissions()))
+	}
+
+	for _, c := range cfg.Certificates {
+		client, err := r.clients.Get(c.Kubeconfig)
+		if err != nil {
+			result = append(result, checker{name: c.Name, config: c, err: err})
+			continue
+		}
+
+		e := &exporters.CertificateExporter{}
+		certificateChecker := checkers.NewCertificateChecker(c.PollingPeriod, c.LabelSelectors, c.AnnotationSelectors, c.Namespaces, client.CertManager.CertmanagerV1(), e)
+		result = append(result, newChecker(c.Name, c, "certificates", c.PollingPeriod, e, certificateChecker.StartChecking).withClient(client, certificateChecker.Permissions()))
+	}
+
+	for _, c := range cfg.Aws {
+		e := &exporters.AwsExporter{PasswordSources: passwordSources(c.PasswordSources, "")}
+		awsChecker := checkers.NewAwsChecker(c.Account, c.Region, c.KeySubString, c.Secrets, c.PollingPeriod, e)
+		result = append(result, newChecker(c.Name, c, "aws", c.PollingPeriod, e, awsChecker.StartChecking))
+	}
+
+	for _, c := range cfg.ConfigMaps {
+		client, err := r.clients.Get(c.Kubeconfig)
+		if err != nil {
+			result = append(result, checker{name: c.Name, config: c, err: err})
+			continue
+		}
+
+		e := &exporters.ConfigMapExporter{}
+		configMapChecker := checkers.NewConfigMapChecker(c.PollingPeriod, c.LabelSelectors, c.IncludeGlobs, c.ExcludeGlobs, c.AnnotationSelectors, c.LabelsToMetric, c.Namespaces, c.NamespaceLabelSelectors, c.ExcludeNamespaces, client.Kubernetes, e, c.ListPageSize, c.ScanConcurrency, expiryThresholds(c.Thresholds))
+		result = append(result, newChecker(c.Name, c, "configmaps", c.PollingPeriod, e, configMapChecker.StartChecking).withClient(client, configMapChecker.Permissions()))
+	}
+
+	for _, c := range cfg.Webhooks {
+		client, err := r.clients.Get(c.Kubeconfig)
+		if err != nil {
+			result = append(result, checker{name: c.Name, config: c, err: err})
+			continue
+		}
+
+		e := &exporters.WebhookExporter{}
+		webhookChecker := checkers.NewWebhookChecker(c.PollingPeriod, c.LabelSelectors, c.AnnotationSelectors, c.LabelsToMetric, client.Kubernetes, e)
+		result = append(result, newChecker(c.Name, c, "webhooks", c.PollingPeriod, e, webhookChecker.StartChecking).withClient(client, webhookChecker.Permissions()))
+	}
+
+	for _, c := range cfg.TLSEndpoints {
+		var targets []checkers.EndpointTarget
+		for _, endpoint := range c.Targets {
+			// targets were validated together with the rest of the config
+			target, _ := checkers.ParseEndpointTarget(endpoint, c.Timeout)
+			targets = append(targets, target)
+		}
+
+		e := &exporters.EndpointExporter{}
+		endpointChecker := checkers.NewEndpointChecker(c.PollingPeriod, targets, e)
+		result = append(result, newChecker(c.Name, c, "tls-endpoints", c.PollingPeriod, e, endpointChecker.StartChecking))
+	}
+
+	for _, c := range cfg.Ingresses {
+		client, err := r.clients.Get(c.Kubeconfig)
+		if err != nil {
+			result = append(result, checker{name: c.Name, config: c, err: err})
+			continue
+		}
+
+		var controllerService *checkers.IngressControllerService
+		if c.ControllerService != "" {
+			svc, _ := checkers.ParseIngressControllerService(c.ControllerService)
+			controllerService = &svc
+		}
+
+		e := &exporters.IngressExporter{}
+		ingressChecker := checkers.NewIngressChecker(c.PollingPeriod, c.LabelSelectors, c.AnnotationSelectors, c.Namespaces, controllerService, c.ProbeTimeout, client.Kubernetes, e)
+		result = append(result, newChecker(c.Name, c, "ingresses", c.PollingPeriod, e, ingressChecker.StartChecking).withClient(client, ingressChecker.Permissions()))
+	}
+
+	return result
+}
+
+// expiryThresholds converts the thresholds of a checker config for its exporter
+func expiryThresholds(t config.Thresholds) exporters.ExpiryThresholds {
+	return exporters.ExpiryThresholds{WarnBefore: t.WarnBefore, CriticalBefore: t.CriticalBefore}
+}
+
+// passwordSources converts the password settings of a checker config for its exporter.  file is only set for certs
+// checkers.
+func passwordSources(p config.PasswordSources, file string) exporters.PasswordSources {
+	return exporters.PasswordSources{File: file, Env: p.PasswordEnv, Candidates: p.CandidatePasswords}
+}
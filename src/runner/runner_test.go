@@ -0,0 +1,227 @@
+package runner
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/joe-elliott/cert-exporter/internal/testutil"
+	"github.com/joe-elliott/cert-exporter/src/config"
+	"github.com/joe-elliott/cert-exporter/src/exporters"
+	"github.com/joe-elliott/cert-exporter/src/kubeclient"
+	"github.com/joe-elliott/cert-exporter/src/metrics"
+)
+
+func TestRunner_Apply(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	dirA, dirB := t.TempDir(), t.TempDir()
+	certA := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "runner-a", Days: 30})
+	certB := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "runner-b", Days: 30})
+	testutil.WriteCertToFile(t, certA.CertPEM, filepath.Join(dirA, "a.crt"))
+	testutil.WriteCertToFile(t, certB.CertPEM, filepath.Join(dirB, "b.crt"))
+
+	newConfig := func(globs ...string) *config.Config {
+		cfg := &config.Config{}
+		for _, glob := range globs {
+			cfg.Certs = append(cfg.Certs, config.FileConfig{IncludeGlobs: []string{glob}})
+		}
+		cfg.ApplyDefaults()
+		return cfg
+	}
+
+	r := New("test-node", kubeclient.NewClients(context.Background(), kubeclient.Options{}), false)
+	defer r.Stop()
+
+	r.Apply(context.Background(), newConfig(dirA+"/*.crt"))
+	waitForCNs(t, testRegistry, "runner-a")
+	first := r.running["certs[0]"]
+
+	// Applying the same config keeps the running checker
+	r.Apply(context.Background(), newConfig(dirA+"/*.crt"))
+	if r.running["certs[0]"] != first {
+		t.Error("Expected an unchanged checker to keep running")
+	}
+
+	// A changed checker is restarted and the series of the old one removed
+	r.Apply(context.Background(), newConfig(dirB+"/*.crt"))
+	if r.running["certs[0]"] == first {
+		t.Error("Expected a changed checker to be restarted")
+	}
+	waitForCNs(t, testRegistry, "runner-b")
+
+	// A checker that is no longer configured is stopped and its series removed
+	r.Apply(context.Background(), newConfig())
+	if len(r.running) != 0 {
+		t.Errorf("Expected no running checkers, got %d", len(r.running))
+	}
+	waitForCNs(t, testRegistry)
+}
+
+func TestRunner_OverlappingCheckers(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	dir := t.TempDir()
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "runner-shared", Days: 30})
+	testutil.WriteCertToFile(t, cert.CertPEM, filepath.Join(dir, "shared.crt"))
+
+	newConfig := func(globs ...string) *config.Config {
+		cfg := &config.Config{}
+		for _, glob := range globs {
+			cfg.Certs = append(cfg.Certs, config.FileConfig{IncludeGlobs: []string{glob}})
+		}
+		cfg.ApplyDefaults()
+		return cfg
+	}
+
+	r := New("test-node", kubeclient.NewClients(context.Background(), kubeclient.Options{}), false)
+	defer r.Stop()
+
+	// Both checkers publish the same series
+	r.Apply(context.Background(), newConfig(dir+"/*.crt", dir+"/shared.crt"))
+	waitForCNs(t, testRegistry, "runner-shared")
+	for _, name := range []string{"certs[0]", "certs[1]"} {
+		for deadline := time.Now().Add(5 * time.Second); !r.running[name].health.Scanned() && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		}
+	}
+
+	// Removing one of them keeps the series the other still publishes
+	r.Apply(context.Background(), newConfig(dir+"/*.crt"))
+	waitForCNs(t, testRegistry, "runner-shared")
+
+	// The series is removed with the last checker publishing it
+	r.Apply(context.Background(), newConfig())
+	waitForCNs(t, testRegistry)
+}
+
+func TestRunner_Probes(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	dir := t.TempDir()
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "runner-probes", Days: 30})
+	testutil.WriteCertToFile(t, cert.CertPEM, filepath.Join(dir, "probes.crt"))
+
+	cfg := &config.Config{Certs: []config.FileConfig{{IncludeGlobs: []string{dir + "/*.crt"}}}}
+	cfg.ApplyDefaults()
+
+	r := New("test-node", kubeclient.NewClients(context.Background(), kubeclient.Options{}), false)
+	defer r.Stop()
+
+	r.Apply(context.Background(), cfg)
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Ready() != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := r.Ready(); err != nil {
+		t.Errorf("Expected the runner to be ready after the first scan, got %v", err)
+	}
+	if err := r.Live(); err != nil {
+		t.Errorf("Expected the runner to be live, got %v", err)
+	}
+
+	r.mu.Lock()
+	r.running["stalled"] = start(checker{
+		name:   "stalled",
+		health: exporters.NewScanHealth("stalled", "certs"),
+		period: time.Millisecond,
+		run:    func(ctx context.Context) { <-ctx.Done() },
+	})
+	r.running["exited"] = start(checker{
+		name:   "exited",
+		health: exporters.NewScanHealth("exited", "certs"),
+		run:    func(ctx context.Context) {},
+	})
+	r.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.Ready(); err == nil || !strings.Contains(err.Error(), "stalled: first scan not finished") {
+		t.Errorf("Expected the runner not to be ready before every first scan, got %v", err)
+	}
+	err := r.Live()
+	if err == nil || !strings.Contains(err.Error(), "stalled: no scan finished") || !strings.Contains(err.Error(), "exited: checker stopped") {
+		t.Errorf("Expected the stalled and exited checkers to fail liveness, got %v", err)
+	}
+}
+
+func TestRunner_ApplyValidates(t *testing.T) {
+	testRegistry := prometheus.NewRegistry()
+	metrics.Init(true, testRegistry)
+
+	dir := t.TempDir()
+	cert := testutil.GenerateCertificate(t, testutil.CertConfig{CommonName: "runner-validate", Days: 30})
+	testutil.WriteCertToFile(t, cert.CertPEM, filepath.Join(dir, "validate.crt"))
+
+	cfg := &config.Config{
+		Certs:   []config.FileConfig{{IncludeGlobs: []string{dir + "/*.crt"}}},
+		Secrets: []config.SecretConfig{{Kubeconfig: filepath.Join(dir, "missing-kubeconfig")}},
+	}
+	cfg.ApplyDefaults()
+
+	r := New("test-node", kubeclient.NewClients(context.Background(), kubeclient.Options{}), false)
+	defer r.Stop()
+
+	err := r.Apply(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "secrets[0]: building kubeconfig") {
+		t.Errorf("Expected an unusable kubeconfig to fail Apply, got %v", err)
+	}
+	if len(r.running) != 0 {
+		t.Errorf("Expected no checker to start after a failed validation, got %d", len(r.running))
+	}
+
+	degraded := New("test-node", kubeclient.NewClients(context.Background(), kubeclient.Options{}), true)
+	defer degraded.Stop()
+
+	if err := degraded.Apply(context.Background(), cfg); err != nil {
+		t.Errorf("Expected a degrading runner to apply the config, got %v", err)
+	}
+	if _, ok := degraded.running["secrets[0]"]; ok || len(degraded.running) != 1 {
+		t.Errorf("Expected only the usable checker to start, got %v", degraded.running)
+	}
+	waitForCNs(t, testRegistry, "runner-validate")
+}
+
+// waitForCNs waits until exactly the given CNs have expiry series
+func waitForCNs(t *testing.T, registry *prometheus.Registry, want ...string) {
+	t.Helper()
+
+	var cns map[string]bool
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mfs, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+
+		cns = map[string]bool{}
+		for _, mf := range mfs {
+			if mf.GetName() != "cert_exporter_cert_expires_in_seconds" {
+				continue
+			}
+			for _, metric := range mf.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "cn" {
+						cns[label.GetValue()] = true
+					}
+				}
+			}
+		}
+
+		matches := len(cns) == len(want)
+		for _, cn := range want {
+			matches = matches && cns[cn]
+		}
+		if matches {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected series for %v, got %v", want, cns)
+}